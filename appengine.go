@@ -19,12 +19,8 @@ var basePath = "./present/"
 func init() {
 	initTemplates(basePath)
 	playScript(basePath, "HTTPTransport")
-	present.PlayEnabled = true
+	playOptions = present.PlayOptions{Enabled: true, Extensions: []string{".go"}}
 
 	// App Engine has no /etc/mime.types
 	mime.AddExtensionType(".svg", "image/svg+xml")
 }
-
-func playable(c present.Code) bool {
-	return present.PlayEnabled && c.Play && c.Ext == ".go"
-}
@@ -0,0 +1,106 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+func init() {
+	runAsm = cachedAsm
+}
+
+// asmCache holds the assembly listings of the files compiled for .asm
+// elements, by their key; see asmKey.
+var asmCache = struct {
+	sync.Mutex
+	m map[[sha256.Size]byte][]byte
+}{m: make(map[[sha256.Size]byte][]byte)}
+
+// cachedAsm compiles the named Go file on its own for an .asm element,
+// unless it has been compiled for the same architecture already and
+// nothing it is compiled from has changed since, and returns the compiler's
+// assembly listing.
+func cachedAsm(filename, goarch string) ([]byte, error) {
+	key, err := asmKey(filename, goarch)
+	if err != nil {
+		return nil, err
+	}
+	asmCache.Lock()
+	out, ok := asmCache.m[key]
+	asmCache.Unlock()
+	if ok {
+		return out, nil
+	}
+
+	tmp, err := ioutil.TempDir(limits.TempDir, "present-asm-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"TMPDIR=" + tmp}
+	if goarch != "" {
+		env = append(env, "GOARCH="+goarch)
+	}
+	out, err = runCommand(filepath.Dir(filename), env,
+		"go", "build", "-gcflags=-S", "-o", filepath.Join(tmp, "out"), filepath.Base(filename))
+	if err != nil {
+		return nil, fmt.Errorf("compiling %s: %v\n%s", filepath.Base(filename), err, out)
+	}
+	asmCache.Lock()
+	asmCache.m[key] = out
+	asmCache.Unlock()
+	return out, nil
+}
+
+// asmKey returns the key of the assembly listing of the named Go file for
+// goarch. It changes with the file's content, the files of the module it
+// is in, whose packages it may import, and the version of the Go toolchain
+// that compiles it.
+func asmKey(filename, goarch string) (key [sha256.Size]byte, err error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return key, err
+	}
+	dir := filepath.Dir(filename)
+	version, err := runCommand(dir, nil, "go", "env", "GOVERSION")
+	if err != nil {
+		return key, fmt.Errorf("go env GOVERSION: %v\n%s", err, version)
+	}
+	stamp, err := dirStamp(moduleRoot(dir))
+	if err != nil {
+		return key, err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", goarch, bytes.TrimSpace(version), stamp)
+	h.Write(src)
+	copy(key[:], h.Sum(nil))
+	return key, nil
+}
+
+// moduleRoot returns the root directory of the module that holds dir, or
+// dir if it is in none.
+func moduleRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
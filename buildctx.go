@@ -0,0 +1,229 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/davelaursen/present-plus/present"
+)
+
+// A buildContext is how the programs run from a present file are built:
+// the file's playground options, which it takes from its directory's config
+// and its header, the file's directory, which paths in the options are
+// relative to, and the runners registered for the directory.
+type buildContext struct {
+	present.PlayOptions
+	dir     string
+	runners map[string][]string // runner commands, by file extension
+
+	// The extensions of the files of the runnable snippets of the present
+	// file and, if it is read-only, their programs without white space.
+	// Programs are checked against them if they are set; see check.
+	exts     map[string]bool
+	programs map[string]bool
+}
+
+// fileBuildContext returns the build context of the named present file,
+// which has the given playground options.
+func fileBuildContext(name string, play present.PlayOptions) buildContext {
+	dir := filepath.Dir(name)
+	config, err := readDirConfig(dir)
+	if err != nil {
+		log.Printf("Error reading directory config file: %v\n", err)
+	}
+	return buildContext{PlayOptions: play, dir: dir, runners: config.PlayRunners}
+}
+
+// docBuildContext returns the build context of the programs run from the
+// present file at the given URL path. The path is sent by the client, so if
+// it does not name a present file, the build context turns the playground
+// off: only documents decide what may run.
+func docBuildContext(urlPath string) buildContext {
+	name := filepath.Join(".", filepath.FromSlash(path.Clean("/"+urlPath)))
+	if urlPath == "" || !isDoc(name) {
+		log.Printf("not running a program from %q, which is not a present file", urlPath)
+		return buildContext{dir: "."}
+	}
+	doc, err := parse(name, 0)
+	if err != nil {
+		log.Printf("%s: %v", name, err)
+		return buildContext{dir: "."}
+	}
+	bc := fileBuildContext(name, doc.Play)
+	bc.exts, bc.programs = make(map[string]bool), make(map[string]bool)
+	for _, s := range doc.Sections {
+		for _, c := range playCode(s.Elem) {
+			if c.Play {
+				bc.exts[c.Ext] = true
+				bc.programs[withoutSpace(c.Program())] = true
+			}
+		}
+	}
+	return bc
+}
+
+// check returns an error unless the program body, sent by the client as a
+// file with the extension ext, may be run from the present file: the file
+// must have runnable snippets with that extension, and if it is read-only,
+// body must be the program of one of them. The programs are compared
+// without white space, which the browser does not keep exactly.
+func (bc buildContext) check(body, ext string) error {
+	if bc.exts == nil {
+		return nil
+	}
+	if !bc.exts[ext] {
+		return fmt.Errorf("this document has no %s snippets to run", ext)
+	}
+	if bc.ReadOnly && !bc.programs[withoutSpace(body)] {
+		return errors.New("the snippets of this document may not be edited")
+	}
+	return nil
+}
+
+// withoutSpace returns s with its white space removed.
+func withoutSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// playToolchains are the Go toolchains that documents may build their
+// programs with: versions, such as go1.22.4, names of commands to look up
+// in PATH, or absolute paths of go commands.
+var playToolchains []string
+
+// allowedToolchain reports whether a document may build its programs with
+// the toolchain goCmd, which is a version, a command name, or an absolute
+// path.
+func allowedToolchain(goCmd string) bool {
+	for _, t := range playToolchains {
+		if t == goCmd || filepath.IsAbs(t) && filepath.Clean(t) == goCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// goBuild is how to build a program written to a temporary directory.
+type goBuild struct {
+	goCmd   string            // the go command
+	dir     string            // directory to run it in
+	flags   []string          // flags for go build or go test
+	pkgs    []string          // the packages to build or test
+	tests   bool              // whether to test the packages rather than build one
+	env     []string          // environment variables to set
+	overlay map[string]string // files to add to the module, for -overlay
+}
+
+// overlayDir is the directory of the module root that a program built in
+// the module appears in. The go tool ignores directories starting with _
+// in patterns such as ./..., so it is never part of the module's own
+// packages.
+const overlayDir = "_present_play"
+
+// build returns how to build the program whose files, named relative to
+// src, have been written to src.
+func (bc buildContext) build(src string, files []string) (*goBuild, error) {
+	b := &goBuild{goCmd: "go", dir: src, pkgs: []string{"."}}
+	modfile := false
+	for _, f := range files {
+		modfile = modfile || f == "go.mod"
+		b.tests = b.tests || strings.HasSuffix(f, "_test.go")
+	}
+
+	if bc.Go != "" {
+		goCmd := bc.Go
+		if strings.ContainsAny(goCmd, `/\`) && !filepath.IsAbs(goCmd) {
+			abs, err := filepath.Abs(filepath.Join(bc.dir, goCmd))
+			if err != nil {
+				return nil, err
+			}
+			goCmd = abs
+		}
+		// The toolchain is run with the server's rights, so the server
+		// chooses which ones documents may ask for.
+		if !allowedToolchain(goCmd) {
+			return nil, fmt.Errorf("Go toolchain %s is not allowed; the server permits those given with -playgo", bc.Go)
+		}
+		switch {
+		case strings.HasPrefix(goCmd, "go1") && !strings.ContainsAny(goCmd, `/\`):
+			b.env = append(b.env, "GOTOOLCHAIN="+goCmd)
+		default:
+			b.goCmd = goCmd
+		}
+	}
+	if bc.GoFlags != "" {
+		b.env = append(b.env, "GOFLAGS="+bc.GoFlags)
+	}
+	b.flags = []string{"-tags", strings.Join(append([]string{"OMIT"}, bc.Tags...), ",")}
+
+	switch {
+	case modfile:
+		// The program is a module of its own.
+		b.env = append(b.env, "GO111MODULE=on")
+		if b.tests {
+			b.pkgs = []string{"./..."}
+		}
+	case bc.Module != "":
+		// The program is built in the document's module, so that it
+		// may import the module's packages, even internal ones. Its
+		// files are laid over a directory of the module.
+		root, err := filepath.Abs(filepath.Join(bc.dir, bc.Module))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+			return nil, fmt.Errorf("module root %s has no go.mod", bc.Module)
+		}
+		b.dir = root
+		b.env = append(b.env, "GO111MODULE=on")
+		b.overlay = make(map[string]string)
+		pkgs := make(map[string]bool)
+		b.pkgs = nil
+		for _, f := range files {
+			b.overlay[filepath.Join(root, overlayDir, filepath.FromSlash(f))] = filepath.Join(src, filepath.FromSlash(f))
+			pkg := "./" + path.Join(overlayDir, path.Dir(f))
+			if strings.HasSuffix(f, ".go") && !pkgs[pkg] && (b.tests || path.Dir(f) == ".") {
+				pkgs[pkg] = true
+				b.pkgs = append(b.pkgs, pkg)
+			}
+		}
+		if len(b.pkgs) == 0 {
+			b.pkgs = []string{"./" + overlayDir}
+		}
+	default:
+		// A lone file is built in GOPATH mode, as it always was.
+		b.env = append(b.env, "GO111MODULE=off")
+		if b.tests {
+			b.pkgs = []string{"./..."}
+		}
+	}
+	return b, nil
+}
+
+// writeOverlay writes the file that the -overlay flag of the go tool reads,
+// which adds the given files, mapped to the files that hold their contents.
+func writeOverlay(name string, files map[string]string) error {
+	b, err := json.Marshal(struct{ Replace map[string]string }{files})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0666)
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davelaursen/present-plus/present"
+)
+
+// inDir runs the test in dir, as the server runs in the directory it
+// serves, with .slide files presentable and the playground on.
+func inDir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	oldTemplates, oldPlay := contentTemplate, playOptions
+	contentTemplate = map[string]*template.Template{".slide": nil}
+	playOptions = present.PlayOptions{Enabled: true}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		contentTemplate, playOptions = oldTemplates, oldPlay
+	})
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const helloGo = "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+
+// slideOf returns a present file with a slide of the given elements.
+func slideOf(elems ...string) string {
+	return "Title\n\nAuthor\n\n* Slide\n\n" + strings.Join(elems, "\n") + "\n"
+}
+
+func TestDocBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"talk.slide":          slideOf(".play hello.go"),
+		"readonly.slide":      "#+play=readonly\n" + slideOf(".play hello.go"),
+		"off.slide":           "#+play=false\n" + slideOf(".play hello.go"),
+		"hello.go":            helloGo,
+		"notes.txt":           "not a present file\n",
+		"py/plus-config.json": `{"playExtensions": [".py"]}`,
+		"py/talk.slide":       slideOf(".play hello.py"),
+		"py/hello.py":         "print('hello')\n",
+	})
+	inDir(t, dir)
+
+	tests := []struct {
+		path     string
+		enabled  bool
+		readOnly bool
+		ext      string // an extension that the context allows
+	}{
+		{"", false, false, ""},
+		{"/missing.slide", false, false, ""},
+		{"/notes.txt", false, false, ""},
+		{"/off.slide", false, false, ""},
+		{"/talk.slide", true, false, ".go"},
+		{"/../../talk.slide", true, false, ".go"},
+		{"/readonly.slide", true, true, ".go"},
+		{"/py/talk.slide", true, false, ".py"},
+	}
+	for _, tt := range tests {
+		bc := docBuildContext(tt.path)
+		if bc.Enabled != tt.enabled || bc.ReadOnly != tt.readOnly {
+			t.Errorf("%q: Enabled, ReadOnly = %v, %v; want %v, %v", tt.path, bc.Enabled, bc.ReadOnly, tt.enabled, tt.readOnly)
+		}
+		if tt.ext != "" && !bc.Allows(tt.ext) {
+			t.Errorf("%q: %s files are not allowed", tt.path, tt.ext)
+		}
+	}
+	if bc := docBuildContext("/py/talk.slide"); bc.Allows(".go") {
+		t.Errorf("/py/talk.slide: .go files are allowed, though the directory allows only .py")
+	}
+}
+
+func TestCheckProgram(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"talk.slide":     slideOf(".play hello.go", ".code hello.py"),
+		"readonly.slide": "#+play=readonly\n" + slideOf(".play hello.go /func main/,/^}/"),
+		"hello.go":       helloGo,
+		"hello.py":       "print('hello')\n",
+	})
+	inDir(t, dir)
+
+	tests := []struct {
+		doc, ext, body string
+		ok             bool
+	}{
+		{"/talk.slide", ".go", helloGo, true},
+		{"/talk.slide", ".go", strings.Replace(helloGo, "hello", "edited", 1), true},
+		// Only the snippets written with .play are run.
+		{"/talk.slide", ".py", "print('hello')\n", false},
+		{"/talk.slide", ".txtar", "-- go.mod --\nmodule m\n", false},
+		{"/talk.slide", ".sh", "#!/bin/sh\necho hello\n", false},
+		// The program of a read-only snippet is sent as the browser
+		// renders it, with its tabs expanded and lines added.
+		{"/readonly.slide", ".go", helloGo, true},
+		{"/readonly.slide", ".go", "\n" + strings.Replace(helloGo, "\t", "    ", -1) + "\n\n", true},
+		{"/readonly.slide", ".go", strings.Replace(helloGo, "hello", "edited", 1), false},
+		{"/readonly.slide", ".go", "package main\n", false},
+	}
+	for _, tt := range tests {
+		err := docBuildContext(tt.doc).check(tt.body, tt.ext)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: check(%q, %s) = %v; want ok %v", tt.doc, tt.body, tt.ext, err, tt.ok)
+		}
+	}
+
+	// The refusal ends the run, before anything is built.
+	events := run("package main\n", &Options{Ext: ".go"}, docBuildContext("/readonly.slide"))
+	if len(events) != 1 || events[0].Kind != "end" || !strings.Contains(events[0].Body, "may not be edited") {
+		t.Errorf("running an edited read-only snippet: got %+v", events)
+	}
+}
+
+func TestBuildToolchain(t *testing.T) {
+	dir := t.TempDir()
+	defer func(old []string) { playToolchains = old }(playToolchains)
+	goCmd := filepath.Join(dir, "bin", "go")
+
+	tests := []struct {
+		goOpt   string
+		allowed []string
+		goCmd   string // the go command run, if allowed
+		env     string // an environment variable set, if allowed
+	}{
+		{"", nil, "go", ""},
+		{"go1.22.4", nil, "", ""},
+		{"go1.22.4", []string{"go1.21.0"}, "", ""},
+		{"go1.22.4", []string{"go1.21.0", "go1.22.4"}, "go", "GOTOOLCHAIN=go1.22.4"},
+		{"gotip", []string{"gotip"}, "gotip", ""},
+		{"bin/go", []string{goCmd}, goCmd, ""},
+		{"bin/go", []string{"bin/go"}, "", ""},
+		{goCmd, []string{goCmd}, goCmd, ""},
+		{"../bin/go", []string{goCmd}, "", ""},
+	}
+	for _, tt := range tests {
+		playToolchains = tt.allowed
+		bc := buildContext{PlayOptions: present.PlayOptions{Enabled: true, Go: tt.goOpt}, dir: dir}
+		b, err := bc.build(dir, []string{"prog.go"})
+		if tt.goCmd == "" {
+			if err == nil {
+				t.Errorf("%q allowing %q: built with %s; want an error", tt.goOpt, tt.allowed, b.goCmd)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q allowing %q: %v", tt.goOpt, tt.allowed, err)
+			continue
+		}
+		if b.goCmd != tt.goCmd {
+			t.Errorf("%q allowing %q: built with %s; want %s", tt.goOpt, tt.allowed, b.goCmd, tt.goCmd)
+		}
+		if tt.env != "" && !strings.Contains(strings.Join(b.env, " "), tt.env) {
+			t.Errorf("%q allowing %q: environment %q does not set %s", tt.goOpt, tt.allowed, b.env, tt.env)
+		}
+	}
+}
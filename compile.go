@@ -0,0 +1,156 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newCompileHandler returns the handler of the /compile endpoint, which
+// checks the origin of requests as the socket handler does.
+func newCompileHandler(origin *url.URL) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := checkOrigin(r, origin); err != nil {
+			log.Println("bad compile request:", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		compileHandler(w, r)
+	})
+}
+
+// checkOrigin returns an error unless the request was made by a page
+// served from origin. Other sites could otherwise run programs through a
+// plain form. The request must carry the X-Requested-With header, which
+// jQuery sets for playground.js, as a form cannot send it and a script on
+// another site cannot send it without a preflight, which is never allowed.
+func checkOrigin(r *http.Request, origin *url.URL) error {
+	if r.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+		return errors.New("missing X-Requested-With header")
+	}
+	from := r.Header.Get("Origin")
+	if from == "" {
+		from = r.Referer()
+	}
+	if from == "" {
+		return errors.New("no Origin or Referer")
+	}
+	u, err := url.Parse(from)
+	if err != nil {
+		return err
+	}
+	if !sameOrigin(origin, u) {
+		return fmt.Errorf("origin %s is not %s", u.Scheme+"://"+u.Host, origin)
+	}
+	return nil
+}
+
+// compileHandler serves the /compile endpoint used by the HTTPTransport of
+// playground.js, for networks that do not let WebSockets through. It runs
+// programs as the socket handler does, with the same limits, and replies
+// with their output once they exit.
+func compileHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("running snippet from:", r.RemoteAddr)
+	// The program's output is read while it is being built.
+	out := make(chan *Message)
+	proc := make(chan *process, 1)
+	go func() {
+		// There is no way to send the program input over HTTP.
+		p := startProcess("", r.FormValue("body"), out, nil, docBuildContext(referrerPath(r)))
+		p.Input("", true)
+		proc <- p
+	}()
+
+	var resp compileResponse
+	last := time.Now()
+	done := r.Context().Done()
+	for {
+		var m *Message
+		select {
+		case m = <-out:
+		case <-done:
+			// The client has gone; stop the program and drain its output.
+			go func() { (<-proc).Kill() }()
+			done = nil
+			continue
+		}
+		if m.Kind == "end" {
+			resp.setEnd(m.Body)
+			break
+		}
+		now := time.Now()
+		resp.Events = append(resp.Events, compileEvent{
+			Message: m.Body,
+			Kind:    m.Kind,
+			Delay:   now.Sub(last),
+		})
+		last = now
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Println(err)
+	}
+}
+
+// referrerPath returns the path of the page that made the request, which
+// for a run is the present file the code is from.
+func referrerPath(r *http.Request) string {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host != r.Host {
+		return ""
+	}
+	return u.Path
+}
+
+// compileResponse is the reply of the /compile endpoint, as expected by
+// playground.js.
+type compileResponse struct {
+	Errors string
+	Events []compileEvent
+	Status int
+}
+
+// A compileEvent is output from a program, sent Delay after the one
+// before it.
+type compileEvent struct {
+	Message string
+	Kind    string // "stdout" or "stderr"
+	Delay   time.Duration
+}
+
+// setEnd records how the program ended, given the body of its "end"
+// message, in the form the client shows it.
+func (r *compileResponse) setEnd(body string) {
+	switch {
+	case body == "":
+	case strings.HasPrefix(body, "exit status "):
+		r.Status, _ = strconv.Atoi(body[len("exit status "):])
+		if r.Status == 0 {
+			r.Status = 1
+		}
+	case strings.HasPrefix(body, "timed out"):
+		// The client plays back the output of a program that took
+		// too long only if the error says exactly this.
+		r.Errors = "process took too long"
+	default:
+		r.Events = append(r.Events, compileEvent{Message: "\n" + body + "\n", Kind: "stderr"})
+		r.Status = 1
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCompileOrigin(t *testing.T) {
+	inDir(t, t.TempDir())
+	origin := &url.URL{Scheme: "http", Host: "localhost:3999"}
+	h := newCompileHandler(origin)
+
+	tests := []struct {
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{"GET", map[string]string{"X-Requested-With": "XMLHttpRequest", "Origin": "http://localhost:3999"}, http.StatusMethodNotAllowed},
+		// A form posted from any site.
+		{"POST", map[string]string{"Origin": "http://localhost:3999"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Origin": "http://evil.example"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Origin": "https://localhost:3999"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Origin": "http://localhost:4000"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Referer": "http://evil.example/talk.slide"}, http.StatusForbidden},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Origin": "http://localhost:3999"}, http.StatusOK},
+		{"POST", map[string]string{"X-Requested-With": "XMLHttpRequest", "Referer": "http://localhost:3999/talk.slide"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "http://localhost:3999/compile", strings.NewReader("body=package+main"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s with %v: status %d; want %d", tt.method, tt.headers, w.Code, tt.status)
+		}
+		// Allowed requests get as far as the playground, which refuses
+		// to run programs that are not from a present file.
+		if w.Code == http.StatusOK && !strings.Contains(w.Body.String(), "turned off") {
+			t.Errorf("%s with %v: got %s; want the program refused", tt.method, tt.headers, w.Body)
+		}
+	}
+}
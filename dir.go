@@ -5,9 +5,12 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -35,7 +38,13 @@ func dirHandler(w http.ResponseWriter, r *http.Request) {
 	const base = "."
 	name := filepath.Join(base, r.URL.Path)
 	if isDoc(name) {
-		err := renderDoc(w, name)
+		var err error
+		if r.FormValue("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			err = writeDocJSON(w, name, false)
+		} else {
+			err = renderDoc(w, name)
+		}
 		if err != nil {
 			log.Println(err)
 			http.Error(w, err.Error(), 500)
@@ -80,7 +89,7 @@ func initTemplates(base string) error {
 
 		// Read and parse the input.
 		tmpl := present.Template()
-		tmpl = tmpl.Funcs(template.FuncMap{"playable": playable})
+		tmpl = tmpl.Funcs(template.FuncMap{"playable": playable, "recordKey": recordKey})
 		if _, err := tmpl.ParseFiles(actionTmpl, contentTmpl); err != nil {
 			return err
 		}
@@ -120,13 +129,133 @@ func renderDoc(w io.Writer, docFile string) error {
 	return doc.Render(w, tmpl)
 }
 
+// writeDocJSON parses the present file and writes its JSON representation
+// to w, indented if indent is set.
+func writeDocJSON(w io.Writer, docFile string, indent bool) error {
+	doc, err := parse(docFile, 0)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "\t")
+	}
+	return enc.Encode(doc)
+}
+
 func parse(name string, mode present.ParseMode) (*present.Doc, error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return present.Parse(f, name, 0)
+	config, err := readDirConfig(filepath.Dir(name))
+	if err != nil {
+		log.Printf("Error reading directory config file: %v\n", err)
+	}
+	ctx := present.Context{
+		ReadFile:   ioutil.ReadFile,
+		ReadDir:    ioutil.ReadDir,
+		ReadFileAt: readFileAt,
+		Output:     runOutput,
+		Asm:        runAsm,
+		Vars:       config.Vars,
+		Play:       config.playOptions(),
+	}
+	return ctx.Parse(f, name, mode)
+}
+
+// playOptions returns the server's playground options as restricted by the
+// directory's config. It also sets how the programs are built.
+func (c DirConfig) playOptions() present.PlayOptions {
+	play := c.restrictPlay(playOptions)
+	if c.PlayModule != "" {
+		play.Module = c.PlayModule
+	}
+	if c.PlayGo != "" {
+		play.Go = c.PlayGo
+	}
+	if c.PlayGoFlags != "" {
+		play.GoFlags = c.PlayGoFlags
+	}
+	if len(c.PlayTags) > 0 {
+		play.Tags = c.PlayTags
+	}
+	return play
+}
+
+// restrictPlay returns the playground options play as restricted by the
+// directory's config: it may turn the playground off, limit it to files with
+// certain extensions, or make runnable snippets read-only.
+func (c DirConfig) restrictPlay(play present.PlayOptions) present.PlayOptions {
+	if c.Play != nil && !*c.Play {
+		play.Enabled = false
+	}
+	if len(c.PlayExtensions) > 0 {
+		if len(play.Extensions) == 0 {
+			play.Extensions = c.PlayExtensions
+		} else {
+			var exts []string
+			for _, e := range play.Extensions {
+				for _, ce := range c.PlayExtensions {
+					if e == ce {
+						exts = append(exts, e)
+					}
+				}
+			}
+			if len(exts) == 0 {
+				play.Enabled = false
+			}
+			play.Extensions = exts
+		}
+	}
+	play.ReadOnly = play.ReadOnly || c.PlayReadOnly
+	return play
+}
+
+// recordKey returns the key of the code's output in the recording of the
+// present file it is in; see the record command.
+func recordKey(c present.Code) string {
+	return fmt.Sprintf("%x", sha256.Sum256(c.Raw))
+}
+
+// playable reports whether the code may be run from the browser.
+func playable(c present.Code) bool {
+	return c.Play
+}
+
+// playOptions holds the playground options of the server.
+var playOptions present.PlayOptions
+
+// readFileAt reads a file at a revision of the repository that holds it.
+// It is nil where revisions cannot be read.
+var readFileAt func(filename, rev string) ([]byte, error)
+
+// runOutput runs the command of an .output element. It is nil where
+// commands cannot be run.
+var runOutput func(dir string, args []string, script bool) ([]byte, error)
+
+// runAsm compiles a file for an .asm element. It is nil where files
+// cannot be compiled.
+var runAsm func(filename, goarch string) ([]byte, error)
+
+// readDirConfig reads the plus-config.json file in the given directory.
+// It returns an empty DirConfig if the directory has no config file.
+func readDirConfig(dir string) (DirConfig, error) {
+	var config DirConfig
+	f, err := os.Open(filepath.Join(dir, dirConfigFile))
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+	defer f.Close()
+
+	jsonParser := json.NewDecoder(f)
+	if err = jsonParser.Decode(&config); err != nil {
+		return DirConfig{}, err
+	}
+	return config, nil
 }
 
 func isDir(path string) bool {
@@ -235,6 +364,11 @@ func parseTheme(name string, doc *present.Doc) {
 		return
 	}
 
+	// The highlight palette applies to the code in both slides and articles.
+	if theme.HighlightStylesheets != nil {
+		theme.ArticleStylesheets = append(theme.ArticleStylesheets, theme.HighlightStylesheets...)
+		theme.SlideStylesheets = append(theme.SlideStylesheets, theme.HighlightStylesheets...)
+	}
 	if theme.ArticleStylesheets != nil {
 		tempArr := []string{}
 		for _, stylesheet := range theme.ArticleStylesheets {
@@ -331,24 +465,9 @@ func dirList(w io.Writer, name string) (isDir bool, err error) {
 			Path:         filepath.ToSlash(filepath.Join(name, fi.Name())),
 			ShowFileName: true,
 		}
-		if e.Name == "plus-config.json" {
-			f2, err2 := os.Open(e.Path)
-			if err2 != nil {
-				log.Printf("Error opening directory config file: %v\n", err)
-				continue
-			}
-			defer f2.Close()
-
-			type DirConfig struct {
-				Title        string `json:"title"`
-				Theme        string `json:"theme"`
-				HidePath     bool   `json:"hidePath"`
-				HideFileName bool   `json:"hideFileName"`
-			}
-			var config DirConfig
-
-			jsonParser := json.NewDecoder(f2)
-			if err = jsonParser.Decode(&config); err != nil {
+		if e.Name == dirConfigFile {
+			config, err := readDirConfig(name)
+			if err != nil {
 				log.Printf("Error parsing JSON object from directory config file: %v\n", err)
 				continue
 			}
@@ -453,10 +572,42 @@ func (s dirEntrySlice) Len() int           { return len(s) }
 func (s dirEntrySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s dirEntrySlice) Less(i, j int) bool { return s[i].Name < s[j].Name }
 
+// dirConfigFile is the name of the file that holds a directory's settings.
+const dirConfigFile = "plus-config.json"
+
+// DirConfig holds the settings read from a directory's config file.
+type DirConfig struct {
+	Title        string            `json:"title"`
+	Theme        string            `json:"theme"`
+	HidePath     bool              `json:"hidePath"`
+	HideFileName bool              `json:"hideFileName"`
+	Vars         map[string]string `json:"vars"`
+
+	// Playground policy for the documents in the directory. It can only
+	// restrict what the server allows.
+	Play           *bool    `json:"play"`           // false turns the playground off
+	PlayExtensions []string `json:"playExtensions"` // extensions of the files that may be run; .go includes .txtar
+	PlayReadOnly   bool     `json:"playReadOnly"`   // show runnable snippets without editing
+
+	// How programs run from the documents in the directory are built;
+	// a document's header may override these. Paths are relative to the
+	// directory.
+	PlayModule  string   `json:"playModule"`  // root of the module to build programs in
+	PlayGo      string   `json:"playGo"`      // go command, or toolchain version such as go1.22.4
+	PlayGoFlags string   `json:"playGoFlags"` // GOFLAGS for the build
+	PlayTags    []string `json:"playTags"`    // build tags
+
+	// Commands that run the snippets of files with other extensions than
+	// .go, such as {".py": ["python3"]}. The snippet's file is added to
+	// the arguments, or replaces {file} in them.
+	PlayRunners map[string][]string `json:"playRunners"`
+}
+
 type Theme struct {
 	DirectoryStylesheets []string `json:"directory-stylesheets"`
 	ArticleStylesheets   []string `json:"article-stylesheets"`
 	SlideStylesheets     []string `json:"slide-stylesheets"`
+	HighlightStylesheets []string `json:"highlight-stylesheets"`
 	HideLastSlide        string   `json:"hide-last-slide"`
 	ClosingMessage       string   `json:"closing-message"`
 }
@@ -12,14 +12,78 @@ golang.org/x/tools repository.
 
 Usage of present:
   -base="": base path for slide template and static resources
+  -config="": server config file (default config.json in the present-plus directory)
+  -cputime=10s: maximum CPU time of a playground program (0 for none)
+  -env="PATH,HOME,...": comma-separated names of environment variables passed to playground runs; a trailing * matches a prefix
   -http="127.0.0.1:3999": HTTP service address (e.g., '127.0.0.1:3999')
-  -nacl=false: use Native Client environment playground (prevents non-Go code execution)
+  -maxoutput=1048576: maximum bytes of output from a playground run (0 for none)
+  -maxruns=4: maximum number of concurrent playground runs (0 for none)
+  -nacl=false: deprecated: Native Client is no longer supported, and the flag is ignored
+  -output="go vet,go test": comma-separated commands, or commands and their first arguments, that .output elements may run
+  -outputscripts=false: permit .output elements to run the scripts of documents with sh (.output -file)
+  -outputtimeout=30s: maximum time an .output command may run
   -orighost="": host component of web origin URL (e.g., 'localhost')
   -play=true: enable playground (permit execution of arbitrary user code)
+  -playgo="": comma-separated Go toolchains that documents may build with (#+playGo): versions, command names or absolute paths
+  -scripts=true: permit the playground to execute shell scripts (snippets that start with #!)
   -theme="black": the default theme to apply when no custom styles are defined
+  -timeout=1m0s: maximum wall-clock time of a playground run (0 for none)
+  -tmpdir="": directory for the temporary files of playground runs
+  -transport="socket": how the browser reaches the playground: 'socket' (WebSocket) or 'http' (for proxies that block WebSockets)
 
-The setup of the Go version of NaCl is documented at:
-https://golang.org/wiki/NativeClient
+Commands:
+  install <github repo path>   install a theme from GitHub
+  uninstall <theme>            remove an installed theme
+  fmt [-w] [files...]          rewrite present files in canonical form; with -w
+                               the files are rewritten in place, otherwise the
+                               result is printed to standard output
+  dump files...                print the parsed document tree of each file as
+                               JSON; the same data is served for a document
+                               requested with ?format=json
+  record files...              run the .play snippets of each file and record
+                               their output in file.play.json, beside it; the
+                               recorded output is played back when a snippet
+                               cannot be run, because the playground is off or
+                               unreachable, and the snippet has not been edited
+
+The limits on playground runs may also be set in the server config file,
+where the flags override them:
+	{
+		"play": {
+			"timeout": "30s",
+			"cpuTime": "5s",
+			"maxOutput": 65536,
+			"maxRuns": 2,
+			"tempDir": "/var/tmp/present",
+			"env": ["PATH", "HOME", "GO*"]
+		}
+	}
+Each run builds and runs its program in a temporary directory of its own,
+which is removed when it ends, with only the listed environment variables.
+
+While a program runs, its output panel has a box for its standard input:
+each line typed is sent to the program, and Ctrl-D ends the input. This
+needs the socket transport; with -transport=http, and when recording,
+programs get no input.
+
+Go snippets are built and run, and snippets of other files that start
+with #! are run as scripts. Snippets in other languages are run by the commands registered
+for their file extensions in the playRunners of a directory's
+plus-config.json file:
+	{
+		"playRunners": {
+			".py": ["python3"],
+			".js": ["node"],
+			".sql": ["sqlite3", ":memory:", ".read {file}"]
+		}
+	}
+The snippet is written to a file, whose name replaces {file} in the
+arguments or is added after them, and the command is run under the same
+limits as Go programs. Runners and scripts need the socket transport.
+
+Only the snippets that a document lets run may be run from it: a program
+is run only as a file of a kind the document has runnable snippets of,
+and, if the document is read-only, only if it is one of them.
 
 Input files are named foo.extension, where "extension" defines the format of
 the generated output. The supported formats are:
@@ -0,0 +1,30 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// dumpFiles implements the dump command, which writes the parsed document
+// tree of each of the named present files to standard output as JSON. It
+// returns the exit status of the command.
+func dumpFiles(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: present-plus dump files...")
+		return 2
+	}
+	status := 0
+	for _, name := range args {
+		if err := writeDocJSON(os.Stdout, name, true); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			status = 2
+		}
+	}
+	return status
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/davelaursen/present-plus/present"
+)
+
+// formatFiles implements the fmt command, which rewrites present files in
+// canonical form. With no files it formats standard input. It returns the
+// exit status of the command.
+func formatFiles(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "write result to (source) file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: present-plus fmt [-w] [files...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "Cannot use -w with standard input")
+			return 2
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			err = formatFile("<standard input>", src, false)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range fs.Args() {
+		src, err := ioutil.ReadFile(name)
+		if err == nil {
+			err = formatFile(name, src, *write)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+		}
+	}
+	return status
+}
+
+// formatFile formats the present source src read from the named file. It
+// writes the result back to the file if write is set and the formatting
+// changed it, or to standard output otherwise.
+func formatFile(name string, src []byte, write bool) error {
+	doc, err := present.Parse(bytes.NewReader(src), name, present.Source)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := doc.Format(&buf); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if !write {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if bytes.Equal(src, buf.Bytes()) {
+		return nil
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, buf.Bytes(), fi.Mode().Perm())
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	readFileAt = gitReadFileAt
+}
+
+// gitReadFileAt reads the file named by filename as it was at the revision
+// rev of the git repository that holds it, using git show.
+func gitReadFileAt(filename, rev string) ([]byte, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") || strings.Contains(rev, ":") {
+		return nil, fmt.Errorf("bad revision %q", rev)
+	}
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	// A path starting with ./ is relative to the directory git runs in.
+	cmd := exec.Command("git", "show", rev+":./"+base)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("reading %s at %s: %s", filename, rev, msg)
+		}
+		return nil, fmt.Errorf("reading %s at %s: %v", filename, rev, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runLimits bound the resources used by the programs run from the
+// playground, so that a runaway snippet cannot take over the machine.
+// Zero values mean no limit.
+type runLimits struct {
+	Timeout   time.Duration // wall-clock time for building and running
+	CPUTime   time.Duration // CPU time of the running program
+	MaxOutput int           // bytes of output sent to the browser
+	MaxRuns   int           // programs building or running at once
+	TempDir   string        // where each run gets a temporary directory
+	Env       []string      // environment variables passed to runs
+}
+
+// limits holds the limits in effect. They are set from the defaults below,
+// then the server's config file, then the command line flags.
+var limits = runLimits{
+	Timeout:   time.Minute,
+	CPUTime:   10 * time.Second,
+	MaxOutput: 1 << 20,
+	MaxRuns:   4,
+	Env: []string{
+		"PATH", "HOME", "USER", "LANG", "LC_*", "GO*", "CGO_*", "CC", "CXX",
+		// needed on Windows
+		"SystemRoot", "ComSpec", "PATHEXT", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+	},
+}
+
+// waitDelay is how long the output of a command run for the playground is
+// waited for once the command has been killed or has exited, in case
+// processes it started hold on to it.
+const waitDelay = time.Second
+
+// runSlots holds a token for each program building or running, when the
+// number of runs is limited.
+var runSlots chan struct{}
+
+// limitFlags registers the flags that set the run limits. They override
+// the config file, so they take effect only if given explicitly.
+func limitFlags() {
+	flag.Duration("timeout", limits.Timeout, "maximum wall-clock time of a playground run (0 for none)")
+	flag.Duration("cputime", limits.CPUTime, "maximum CPU time of a playground program (0 for none)")
+	flag.Int("maxoutput", limits.MaxOutput, "maximum bytes of output from a playground run (0 for none)")
+	flag.Int("maxruns", limits.MaxRuns, "maximum number of concurrent playground runs (0 for none)")
+	flag.String("tmpdir", limits.TempDir, "directory for the temporary files of playground runs")
+	flag.String("env", strings.Join(limits.Env, ","), "comma-separated names of environment variables passed to playground runs; a trailing * matches a prefix")
+}
+
+// setLimits sets the run limits from the server config file and the flags
+// given on the command line.
+func setLimits(configFile string) error {
+	if err := readServerConfig(configFile); err != nil {
+		return err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		v := f.Value.(flag.Getter).Get()
+		switch f.Name {
+		case "timeout":
+			limits.Timeout = v.(time.Duration)
+		case "cputime":
+			limits.CPUTime = v.(time.Duration)
+		case "maxoutput":
+			limits.MaxOutput = v.(int)
+		case "maxruns":
+			limits.MaxRuns = v.(int)
+		case "tmpdir":
+			limits.TempDir = v.(string)
+		case "env":
+			limits.Env = splitList(v.(string))
+		}
+	})
+	if limits.Timeout < 0 || limits.CPUTime < 0 || limits.MaxOutput < 0 || limits.MaxRuns < 0 {
+		return errors.New("run limits must not be negative")
+	}
+	if limits.MaxRuns > 0 {
+		runSlots = make(chan struct{}, limits.MaxRuns)
+	}
+	return nil
+}
+
+// serverConfigFile is the name of the server's config file in the
+// present-plus directory.
+const serverConfigFile = "config.json"
+
+// serverConfig holds the settings read from the server's config file.
+type serverConfig struct {
+	Play struct {
+		Timeout   string    `json:"timeout"`
+		CPUTime   string    `json:"cpuTime"`
+		MaxOutput *int      `json:"maxOutput"`
+		MaxRuns   *int      `json:"maxRuns"`
+		TempDir   string    `json:"tempDir"`
+		Env       *[]string `json:"env"`
+	} `json:"play"`
+}
+
+// readServerConfig reads the run limits set in the given config file. If no
+// file is given, it reads the config file in the present-plus directory, if
+// there is one.
+func readServerConfig(name string) error {
+	if name == "" {
+		name = filepath.Join(plusDirPath, serverConfigFile)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var config serverConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	play := config.Play
+	for _, d := range []struct {
+		s string
+		v *time.Duration
+	}{{play.Timeout, &limits.Timeout}, {play.CPUTime, &limits.CPUTime}} {
+		if d.s == "" {
+			continue
+		}
+		if *d.v, err = time.ParseDuration(d.s); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if play.MaxOutput != nil {
+		limits.MaxOutput = *play.MaxOutput
+	}
+	if play.MaxRuns != nil {
+		limits.MaxRuns = *play.MaxRuns
+	}
+	if play.TempDir != "" {
+		limits.TempDir = play.TempDir
+	}
+	if play.Env != nil {
+		limits.Env = *play.Env
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// runEnviron returns the environment of the server process, scrubbed of
+// the variables that are not passed to runs.
+func runEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name := kv
+		if i := strings.Index(kv, "="); i > 0 {
+			name = kv[:i]
+		}
+		for _, pat := range limits.Env {
+			if name == pat || strings.HasSuffix(pat, "*") && strings.HasPrefix(name, pat[:len(pat)-1]) {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return env
+}
+
+// cpuLimited returns the command line args changed to run the command
+// with its CPU time limited. The limit is set by the shell, so it is not
+// applied on Windows.
+func cpuLimited(args []string) []string {
+	if limits.CPUTime <= 0 || runtime.GOOS == "windows" {
+		return args
+	}
+	secs := int((limits.CPUTime + time.Second - 1) / time.Second)
+	script := "ulimit -t " + strconv.Itoa(secs) + ` && exec "$0" "$@"`
+	return append([]string{"/bin/sh", "-c", script}, args...)
+}
@@ -20,9 +20,6 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-
-	"github.com/davelaursen/present-plus/present"
-	"golang.org/x/tools/playground/socket"
 )
 
 const basePkg = "github.com/davelaursen/present-plus"
@@ -36,12 +33,50 @@ func main() {
 	httpAddr := flag.String("http", "127.0.0.1:4999", "HTTP service address (e.g., '127.0.0.1:4999')")
 	originHost := flag.String("orighost", "", "host component of web origin URL (e.g., 'localhost')")
 	flag.StringVar(&basePath, "base", "", "base path for slide template and static resources")
-	flag.BoolVar(&present.PlayEnabled, "play", true, "enable playground (permit execution of arbitrary user code)")
-	nativeClient := flag.Bool("nacl", false, "use Native Client environment playground (prevents non-Go code execution)")
+	flag.BoolVar(&playOptions.Enabled, "play", true, "enable playground (permit execution of arbitrary user code)")
+	playGo := flag.String("playgo", "", "comma-separated Go toolchains that documents may build with (#+playGo): versions, command names or absolute paths")
+	flag.BoolVar(&runScripts, "scripts", true, "permit the playground to execute shell scripts (snippets that start with #!)")
+	nativeClient := flag.Bool("nacl", false, "deprecated: Native Client is no longer supported, and the flag is ignored")
+	transport := flag.String("transport", "socket", "how the browser reaches the playground: 'socket' (WebSocket) or 'http' (for proxies that block WebSockets)")
+	configFile := flag.String("config", "", "server config file (default config.json in the present-plus directory)")
+	limitFlags()
+	outputs := flag.String("output", strings.Join(outputCommands, ","), "comma-separated commands, or commands and their first arguments, that .output elements may run")
+	flag.BoolVar(&outputScripts, "outputscripts", outputScripts, "permit .output elements to run the scripts of documents with sh (.output -file)")
+	flag.DurationVar(&outputTimeout, "outputtimeout", outputTimeout, "maximum time an .output command may run")
 	flag.StringVar(&defaultTheme, "theme", "", "the default theme to apply when no custom styles are defined")
 	flag.StringVar(&repoPath, "repo", "", "path for theme repository")
 	flag.Parse()
 
+	// A command may be followed by flags, as well as by its arguments,
+	// except fmt, which has flags of its own.
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "fmt" {
+		flag.CommandLine.Parse(args[1:])
+		args = append([]string{args[0]}, flag.Args()...)
+	}
+	if *nativeClient {
+		log.Println("The -nacl flag is deprecated and ignored: Native Client is no longer supported.")
+	}
+
+	plusDirPath = getPlusDirPath()
+	if err := setLimits(*configFile); err != nil {
+		log.Fatalf("Failed to read config: %v", err)
+	}
+	outputCommands = splitList(*outputs)
+	playToolchains = splitList(*playGo)
+
+	// Commands that only work on present files need no further setup.
+	if len(args) > 0 {
+		switch args[0] {
+		case "fmt":
+			os.Exit(formatFiles(args[1:]))
+		case "dump":
+			os.Exit(dumpFiles(args[1:]))
+		case "record":
+			os.Exit(recordFiles(args[1:]))
+		}
+	}
+
 	if repoPath != "" {
 		if _, err := os.Stat(repoPath); os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "Repo directory '%s' does not exist\n", repoPath)
@@ -65,13 +100,11 @@ func main() {
 			os.Exit(1)
 		}
 	}
-	plusDirPath = getPlusDirPath()
 	if repoPath == "" {
 		repoPath, _ = filepath.Abs(filepath.Join(plusDirPath, "themes"))
 	}
 
-	args := os.Args[1:]
-	if len(args) > 0 && args[0][0:1] != "-" {
+	if len(args) > 0 {
 		switch args[0] {
 		case "install":
 			installTheme(args)
@@ -118,23 +151,21 @@ func main() {
 		}
 	}
 
-	if present.PlayEnabled {
-		if *nativeClient {
-			socket.RunScripts = false
-			socket.Environ = func() []string {
-				if runtime.GOARCH == "amd64" {
-					return environ("GOOS=nacl", "GOARCH=amd64p32")
-				}
-				return environ("GOOS=nacl")
-			}
+	if playOptions.Enabled {
+		switch *transport {
+		case "socket":
+			playScript(basePath, "PlusSocketTransport")
+			http.Handle("/socket", newSocketHandler(origin))
+		case "http":
+			playScript(basePath, "HTTPTransport")
+			http.Handle("/compile", newCompileHandler(origin))
+		default:
+			log.Fatalf("Unknown transport %q", *transport)
 		}
-		playScript(basePath, "SocketTransport")
-		http.Handle("/socket", socket.NewHandler(origin))
 	}
 	http.Handle("/static/", http.FileServer(http.Dir(basePath)))
 
-	if !ln.Addr().(*net.TCPAddr).IP.IsLoopback() &&
-		present.PlayEnabled && !*nativeClient {
+	if !ln.Addr().(*net.TCPAddr).IP.IsLoopback() && playOptions.Enabled {
 		log.Print(localhostWarning)
 	}
 
@@ -222,12 +253,10 @@ func uninstallTheme(args []string) {
 	}
 }
 
-func playable(c present.Code) bool {
-	return present.PlayEnabled && c.Play
-}
-
+// environ returns the environment for playground runs with the given
+// variables set.
 func environ(vars ...string) []string {
-	env := os.Environ()
+	env := runEnviron()
 	for _, r := range vars {
 		k := strings.SplitAfter(r, "=")[0]
 		var found bool
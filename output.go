@@ -0,0 +1,198 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	runOutput = cachedOutput
+}
+
+var (
+	// outputCommands are the commands that .output elements may run: a
+	// command may be given with the first of its arguments, as "go vet"
+	// is, to allow only those.
+	outputCommands = []string{"go vet", "go test"}
+
+	// outputScripts is whether .output elements may run scripts of the
+	// documents with sh, which need not be among outputCommands.
+	outputScripts = false
+
+	// outputTimeout bounds how long an .output command may run.
+	outputTimeout = 30 * time.Second
+)
+
+// outputCache holds the output of the commands run for .output elements,
+// with the state of the files they were run on.
+var outputCache = struct {
+	sync.Mutex
+	m map[string]outputEntry
+}{m: make(map[string]outputEntry)}
+
+type outputEntry struct {
+	stamp string // the files' state when the command ran; see dirStamp
+	out   []byte
+}
+
+// cachedOutput runs the command args in dir for an .output element, unless
+// it has run already and none of the files in dir has changed since. The
+// command is a script run with sh if script is set.
+func cachedOutput(dir string, args []string, script bool) ([]byte, error) {
+	switch {
+	case script && !outputScripts:
+		return nil, errors.New("scripts may not be run by .output; see the -outputscripts flag")
+	case !script && !allowedOutput(args):
+		return nil, fmt.Errorf("%s may not be run by .output; see the -output flag", strings.Join(args, " "))
+	}
+	key := dir + "\x00" + strings.Join(args, "\x00")
+	stamp, err := dirStamp(dir)
+	if err != nil {
+		return nil, err
+	}
+	outputCache.Lock()
+	e, ok := outputCache.m[key]
+	outputCache.Unlock()
+	if ok && e.stamp == stamp {
+		return e.out, nil
+	}
+
+	// A command that fails, or runs for too long, is shown with why, and
+	// not run again until the files change.
+	out, err := runCommand(dir, nil, args...)
+	note := func(format string, args ...interface{}) {
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			out = append(out, '\n')
+		}
+		out = append(out, fmt.Sprintf("["+format+"]\n", args...)...)
+	}
+	switch err.(type) {
+	case nil:
+	case timeoutError, *exec.ExitError:
+		note("%v", err)
+	default:
+		return nil, err
+	}
+	outputCache.Lock()
+	outputCache.m[key] = outputEntry{stamp, out}
+	outputCache.Unlock()
+	return out, nil
+}
+
+// allowedOutput reports whether the command args may be run by .output:
+// it must start with one of outputCommands, and not have the go command
+// run other programs.
+func allowedOutput(args []string) bool {
+	if args[0] == "go" {
+		for _, a := range args[1:] {
+			for _, f := range []string{"-exec", "-toolexec", "-vettool"} {
+				if a == f || strings.HasPrefix(a, f+"=") || a == "-"+f || strings.HasPrefix(a, "-"+f+"=") {
+					return false
+				}
+			}
+		}
+	}
+	for _, c := range outputCommands {
+		fields := strings.Fields(c)
+		if len(fields) > 0 && len(fields) <= len(args) && strings.Join(args[:len(fields)], " ") == strings.Join(fields, " ") {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutError is the error of a command that ran for too long.
+type timeoutError time.Duration
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v", time.Duration(e))
+}
+
+// runCommand runs the command args in dir, with the environment of
+// playground runs and the variables env, and returns its output and the
+// error it failed with, if it did. The command is killed, with the
+// processes it starts, if it runs for longer than outputTimeout; those it
+// leaves running when it exits are killed too. Its output goes to a file,
+// so that those processes cannot keep it from being waited for, and is
+// truncated at the output limit of runs.
+func runCommand(dir string, env []string, args ...string) ([]byte, error) {
+	f, err := ioutil.TempFile(limits.TempDir, "present-output-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), outputTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	setProcessGroup(cmd)
+	cmd.Dir = dir
+	cmd.Env = environ(env...)
+	cmd.Stdout = f
+	cmd.Stderr = f
+	runErr := cmd.Run()
+	if cmd.Process != nil {
+		killProcessGroup(cmd.Process)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var r io.Reader = f
+	if limits.MaxOutput > 0 {
+		r = io.LimitReader(f, int64(limits.MaxOutput))
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return out, timeoutError(outputTimeout)
+	}
+	return out, runErr
+}
+
+// dirStamp returns a summary of the state of the files in dir and its
+// subdirectories: the number of files, and the latest time one was
+// modified. Present files and recordings are left out, so that editing a
+// deck does not run its commands again; so are hidden directories.
+func dirStamp(dir string) (string, error) {
+	var n int
+	var latest time.Time
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if path != dir && strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isDoc(path) || strings.HasSuffix(path, ".play.json") {
+			return nil
+		}
+		n++
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+		return nil
+	})
+	return fmt.Sprintf("%d %d", n, latest.UnixNano()), err
+}
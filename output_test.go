@@ -0,0 +1,94 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davelaursen/present-plus/present"
+)
+
+func TestAllowedOutput(t *testing.T) {
+	defer func(old []string) { outputCommands = old }(outputCommands)
+	outputCommands = []string{"go vet", "go test", "make"}
+	tests := []struct {
+		cmd     string
+		allowed bool
+	}{
+		{"go vet ./...", true},
+		{"go test -run=^$ -bench=.", true},
+		{"make", true},
+		{"make bench", true},
+		{"go", false},
+		{"go run .", false},
+		{"go vetx", false},
+		{"gofmt -l .", false},
+		{"sh bench.sh", false},
+		{"go test -exec=./evil .", false},
+		{"go test -exec ./evil .", false},
+		{"go test --toolexec=./evil .", false},
+		{"go vet -vettool=./evil .", false},
+	}
+	for _, tt := range tests {
+		if got := allowedOutput(strings.Fields(tt.cmd)); got != tt.allowed {
+			t.Errorf("allowedOutput(%q) = %v; want %v", tt.cmd, got, tt.allowed)
+		}
+	}
+}
+
+func TestOutputPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"talk.slide":   "Title\n\nAuthor\n\n* Slide\n\n.output make\n",
+		"script.slide": "Title\n\nAuthor\n\n* Slide\n\n.output -file bench.sh\n",
+		"off.slide":    "#+play=false\nTitle\n\nAuthor\n\n* Slide\n\n.output make\n",
+		"bench.sh":     "echo bench\n",
+	})
+	inDir(t, dir)
+	defer func(old func(string, []string, bool) ([]byte, error)) { runOutput = old }(runOutput)
+	var ran []string
+	runOutput = func(dir string, args []string, script bool) ([]byte, error) {
+		ran = args
+		return []byte("ran"), nil
+	}
+
+	// Commands are not run when the playground is off, for the server or
+	// for the document.
+	for _, test := range []struct {
+		name string
+		play bool
+	}{
+		{"talk.slide", false},
+		{"off.slide", true},
+	} {
+		ran = nil
+		playOptions.Enabled = test.play
+		doc, err := parse(test.name, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if o := doc.Sections[0].Elem[0].(present.Output); ran != nil || !strings.Contains(o.Text, "not run") {
+			t.Errorf("%s with -play=%v: ran %q, showing %q", test.name, test.play, ran, o.Text)
+		}
+	}
+
+	playOptions.Enabled = true
+	if _, err := parse("talk.slide", 0); err != nil || strings.Join(ran, " ") != "make" {
+		t.Errorf("talk.slide: ran %q, error %v; want make run", ran, err)
+	}
+
+	// Scripts are run only with -outputscripts, and commands only if
+	// they are allowed.
+	runOutput = cachedOutput
+	if _, err := parse("script.slide", 0); err == nil || !strings.Contains(err.Error(), "-outputscripts") {
+		t.Errorf("script.slide: got error %v; want scripts refused", err)
+	}
+	if _, err := parse("talk.slide", 0); err == nil || !strings.Contains(err.Error(), "-output flag") {
+		t.Errorf("talk.slide: got error %v; want make refused", err)
+	}
+}
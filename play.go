@@ -19,7 +19,9 @@ var scripts = []string{"jquery.js", "jquery-ui.js", "playground.js", "play.js"}
 
 // playScript registers an HTTP handler at /play.js that serves all the
 // scripts specified by the variable above, and appends a line that
-// initializes the playground with the specified transport.
+// initializes the playground with the specified transport. The transport
+// plays back the recorded output of snippets if it is unavailable; see
+// static/playback.js.
 func playScript(root, transport string) {
 	modTime := time.Now()
 	var buf bytes.Buffer
@@ -34,7 +36,7 @@ func playScript(root, transport string) {
 		}
 		buf.Write(b)
 	}
-	fmt.Fprintf(&buf, "\ninitPlayground(new %v());\n", transport)
+	fmt.Fprintf(&buf, "\ninitPlayground(new RecordingTransport(new %v()));\n", transport)
 	b := buf.Bytes()
 	http.HandleFunc("/play.js", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-type", "application/javascript")
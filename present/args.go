@@ -15,7 +15,8 @@ import (
 // It's an evaluator for the file address syntax implemented by acme and sam,
 // but using Go-native regular expressions.
 // To keep things reasonably close, this version uses (?m:re) for all user-provided
-// regular expressions. That is the only change to the code from codewalk.go.
+// regular expressions. Apart from that, the only change to the code from
+// codewalk.go is the implementation of backward regular expression searches.
 // See http://plan9.bell-labs.com/sys/doc/sam/sam.html Table II
 // for details on the syntax.
 
@@ -98,6 +99,7 @@ func addrToByteRange(addr string, start int, data []byte) (lo, hi int, err error
 			}
 			pattern := addr[1:i]
 			lo, hi, err = addrRegexp(data, lo, hi, dir, pattern)
+			dir = 0
 			prevc = c
 			addr = addr[j:]
 			continue
@@ -202,7 +204,7 @@ func addrNumber(data []byte, lo, hi int, dir byte, n int, charOffset bool) (int,
 
 // addrRegexp searches for pattern in the given direction starting at lo, hi.
 // The direction dir is '+' (search forward from hi) or '-' (search backward from lo).
-// Backward searches are unimplemented.
+// Both searches wrap around the ends of data if there is no match.
 func addrRegexp(data []byte, lo, hi int, dir byte, pattern string) (int, int, error) {
 	// We want ^ and $ to work as in sam/acme, so use ?m.
 	re, err := regexp.Compile("(?m:" + pattern + ")")
@@ -210,9 +212,15 @@ func addrRegexp(data []byte, lo, hi int, dir byte, pattern string) (int, int, er
 		return 0, 0, err
 	}
 	if dir == '-' {
-		// Could implement reverse search using binary search
-		// through file, but that seems like overkill.
-		return 0, 0, errors.New("reverse search not implemented")
+		m := lastMatch(re, data, lo)
+		if m == nil && lo < len(data) {
+			// No match.  Wrap to end of data.
+			m = lastMatch(re, data, len(data))
+		}
+		if m == nil {
+			return 0, 0, errors.New("no match for " + pattern)
+		}
+		return m[0], m[1], nil
 	}
 	m := re.FindIndex(data[hi:])
 	if len(m) > 0 {
@@ -227,3 +235,15 @@ func addrRegexp(data []byte, lo, hi int, dir byte, pattern string) (int, int, er
 	}
 	return m[0], m[1], nil
 }
+
+// lastMatch returns the last match of re that lies within data[:end] and
+// starts before end, or nil if there is none.
+func lastMatch(re *regexp.Regexp, data []byte, end int) []int {
+	var last []int
+	for _, m := range re.FindAllIndex(data[:end], -1) {
+		if m[0] < end {
+			last = m
+		}
+	}
+	return last
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import "testing"
+
+const addrSrc = `package main
+
+func a() {
+	x()
+}
+
+func b() {
+	y()
+}
+`
+
+func TestAddrToByteRange(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"", addrSrc},
+		{"3", "func a() {\n"},
+		{"3,5", "func a() {\n\tx()\n}\n"},
+		{"/^func b/,/^}/", "func b() {\n\ty()\n}"},
+		{"/^func b/+/^}/", "}"},
+		// Backward searches.
+		{"/y/-/^func/", "func"},
+		{"/y/-/^func/,/^}/", "func b() {\n\ty()\n}"},
+		{"$-/^}/", "}"},
+		{"$-/^}/-/^func/,$-/^}/", "func b() {\n\ty()\n}"},
+		{"/^}/-/^func/,/^}/", "func a() {\n\tx()\n}"},
+		// Backward search from before the first match wraps to the end.
+		{"-/^func/", "func"},
+		{"/^package/-/^func/,/^}/", "func b() {\n\ty()\n}"},
+	}
+	for _, tt := range tests {
+		lo, hi, err := addrToByteRange(tt.addr, 0, []byte(addrSrc))
+		if err != nil {
+			t.Errorf("%q: %v", tt.addr, err)
+			continue
+		}
+		if got := addrSrc[lo:hi]; got != tt.want {
+			t.Errorf("%q: got %q; want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	for _, addr := range []string{"-/^nope/", "/^nope/", "/y/-/x(/"} {
+		if _, _, err := addrToByteRange(addr, 0, []byte(addrSrc)); err == nil {
+			t.Errorf("%q: got no error", addr)
+		}
+	}
+}
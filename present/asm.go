@@ -0,0 +1,216 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("asm", parseAsm)
+}
+
+// Asm is the assembly that the compiler generates for a Go function,
+// interleaved with the lines of source it was generated from.
+type Asm struct {
+	Pos
+	Text     template.HTML
+	FileName string // name of the file, as written
+	Func     string // name of the function, such as add or T.Inc
+	GOARCH   string // architecture compiled for, or empty for that of the host
+}
+
+func (a Asm) TemplateName() string { return "asm" }
+
+// notCompiled is shown in place of the assembly of functions that are not
+// compiled because the playground is off.
+const notCompiled = "[not compiled: the playground is turned off]"
+
+var (
+	asmRE     = regexp.MustCompile(`^\.asm\s+((?:-numbers\s+)?)([^\s]+)\s+([^\s]+)(?:\s+([a-z0-9]+))?$`)
+	asmInsRE  = regexp.MustCompile(`^\s+0x[0-9a-f]+\s+(\d+)\s+\(([^)]*)\)\s+(\S+)\s*(.*)$`)
+	asmFuncRE = regexp.MustCompile(`^(\S+) STEXT`)
+)
+
+// parseAsm parses an asm present directive. Its syntax:
+//   .asm [-numbers] <filename> <func> [GOARCH] [highlight]
+// The function is named as in a symbol address, such as add or T.Inc.
+// Lines of the function marked with // HL, or selected by the highlight
+// steps, are highlighted with the instructions generated for them. Nothing
+// is compiled if the playground is off.
+func parseAsm(ctx *Context, sourceFile string, sourceLine int, cmd string) (Elem, error) {
+	cmd = strings.TrimSpace(cmd)
+	steps := []highlightStep{{}}
+	if hl := highlightRE.FindStringSubmatchIndex(cmd); len(hl) == 4 {
+		steps = parseHighlightSteps(cmd[hl[2]:hl[3]])
+		cmd = cmd[:hl[0]]
+	}
+	args := asmRE.FindStringSubmatch(cmd)
+	if args == nil {
+		return nil, fmt.Errorf("%s:%d: syntax error for .asm invocation", sourceFile, sourceLine)
+	}
+	a := Asm{
+		Pos:      linePos(sourceFile, sourceLine),
+		FileName: args[2],
+		Func:     args[3],
+		GOARCH:   args[4],
+	}
+	if ctx.Asm == nil {
+		return nil, fmt.Errorf("%s:%d: cannot compile %s: .asm is not supported", sourceFile, sourceLine, a.FileName)
+	}
+	// The compiler runs with the rights of whoever presents the document,
+	// as .output commands do, so only where snippets may run.
+	if !ctx.Play.Enabled {
+		a.Text = template.HTML("<pre>" + html.EscapeString(notCompiled) + "</pre>")
+		return a, nil
+	}
+	filename := filepath.Join(filepath.Dir(sourceFile), a.FileName)
+	src, err := ctx.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+	}
+	out, err := ctx.Asm(filename, a.GOARCH)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+	}
+	ins, err := funcAsm(out, filepath.Base(filename), a.Func)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %s: %v", sourceFile, sourceLine, a.FileName, err)
+	}
+
+	// The source lines are highlighted, and given their highlight steps,
+	// as .code does; their instructions follow suit.
+	source := formatLines(codeLines(src, 0, len(src)), steps)
+	highlightLines(source, ".go")
+	byNum := make(map[int]codeLine)
+	for _, l := range source {
+		byNum[l.N] = l
+	}
+	data := &asmTemplateData{Numbers: args[1] != ""}
+	if len(steps) > 1 {
+		data.Steps = len(steps)
+	}
+	last := 0
+	for _, in := range ins {
+		if in.line != last {
+			if l, ok := byNum[in.line]; ok {
+				data.Lines = append(data.Lines, asmLine{codeLine: l})
+				last = in.line
+			}
+		}
+		l := byNum[last]
+		l.N = in.line
+		l.H = template.HTML(html.EscapeString(in.text))
+		data.Lines = append(data.Lines, asmLine{codeLine: l, Ins: true})
+	}
+
+	var buf bytes.Buffer
+	if err := asmTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	a.Text = template.HTML(buf.String())
+	return a, nil
+}
+
+// An asmIns is an instruction of the compiler's assembly listing.
+type asmIns struct {
+	line int    // line of source it was generated from, or 0 if unknown
+	text string // offset, opcode and operands
+}
+
+// funcAsm returns the instructions that the compiler's -S listing out holds
+// for the function fn, as generated from the file named base. Instructions
+// generated from other files, such as those of inlined functions, are
+// attributed to the line of the file before them. Pseudo-instructions that
+// only carry data for the runtime are left out.
+func funcAsm(out []byte, base, fn string) ([]asmIns, error) {
+	var ins []asmIns
+	found, in := false, false
+	s := bufio.NewScanner(bytes.NewReader(out))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		text := s.Text()
+		if m := asmFuncRE.FindStringSubmatch(text); m != nil {
+			in = !found && asmFuncName(m[1]) == fn
+			found = found || in
+			continue
+		}
+		m := asmInsRE.FindStringSubmatch(text)
+		if !in || m == nil || m[3] == "FUNCDATA" || m[3] == "PCDATA" {
+			continue
+		}
+		line := 0
+		if i := strings.LastIndex(m[2], ":"); i >= 0 && filepath.Base(m[2][:i]) == base {
+			line, _ = strconv.Atoi(m[2][i+1:])
+		}
+		if line == 0 && len(ins) > 0 {
+			line = ins[len(ins)-1].line
+		}
+		ins = append(ins, asmIns{line, fmt.Sprintf("    %s  %-7s %s", m[1], m[3], m[4])})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no function %s in the compiler's output", fn)
+	}
+	return ins, nil
+}
+
+// asmFuncName returns the name of the function whose symbol in an assembly
+// listing is sym, as it is written in an .asm invocation: without its
+// package, the pointer of its receiver, or its type arguments. For
+// example, the name of main.(*T).Inc is T.Inc.
+func asmFuncName(sym string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range sym {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	sym = b.String()
+	if i := strings.Index(sym, "."); i >= 0 {
+		sym = sym[i+1:]
+	}
+	if strings.HasPrefix(sym, "(*") {
+		sym = strings.Replace(sym[2:], ")", "", 1)
+	}
+	return sym
+}
+
+type asmTemplateData struct {
+	Lines   []asmLine
+	Numbers bool
+	Steps   int // number of highlight steps, if more than one
+}
+
+// An asmLine is a line of source, or an instruction generated from it.
+type asmLine struct {
+	codeLine
+	Ins bool // whether the line is an instruction
+}
+
+var asmTemplate = template.Must(template.New("asm").Funcs(codeFuncs).Parse(asmTemplateHTML))
+
+const asmTemplateHTML = `
+<pre{{if .Numbers}} class="numbers"{{end}}{{with .Steps}} steps="{{.}}"{{end}}>{{/*
+	*/}}{{range .Lines}}<span num="{{.N}}" class="{{if .Ins}}asm-ins{{else}}asm-src{{end}}{{if .HL}} hl{{end}}"{{/*
+	*/}}{{with .Steps}} steps="{{join .}}"{{end}}>{{.H}}</span>
+{{end}}</pre>
+`
@@ -0,0 +1,144 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const asmSrc = `package main
+
+type T struct{ n int }
+
+func (t *T) Inc() { t.n++ }
+
+func add(a, b int) int {
+	c := a + b // HL
+	return c * 2
+}
+`
+
+const asmListing = `# command-line-arguments
+main.(*T).Inc STEXT nosplit size=4 args=0x8 locals=0x0
+	0x0000 00000 (/tmp/x/add.go:5)	TEXT	main.(*T).Inc(SB), NOSPLIT|NOFRAME|ABIInternal, $0-8
+	0x0000 00000 (/tmp/x/add.go:5)	FUNCDATA	$0, gclocals·wvjpxkknJ4nY1JtrArJJaw==(SB)
+	0x0000 00000 (/tmp/x/add.go:5)	INCQ	(AX)
+	0x0003 00003 (/tmp/x/add.go:5)	RET
+	0x0000 48 ff 00 c3                                      H...
+main.add STEXT nosplit size=9 args=0x10 locals=0x0
+	0x0000 00000 (/tmp/x/add.go:7)	TEXT	main.add(SB), NOSPLIT|NOFRAME|ABIInternal, $0-16
+	0x0000 00000 (/tmp/x/add.go:7)	PCDATA	$3, $1
+	0x0000 00000 (/tmp/x/add.go:8)	LEAQ	(BX)(AX*1), CX
+	0x0004 00004 (<unknown line number>)	NOP
+	0x0004 00004 (/tmp/x/add.go:9)	LEAQ	(CX)(CX*1), AX
+	0x0008 00008 (/tmp/x/add.go:9)	RET
+	0x0000 48 8d 0c 03 48 8d 04 09 c3                       H...H....
+`
+
+var tagRE = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags returns the text of the HTML s.
+func stripTags(s string) string {
+	return html.UnescapeString(tagRE.ReplaceAllString(s, ""))
+}
+
+func TestAsm(t *testing.T) {
+	var gotArch string
+	ctx := &Context{
+		ReadFile: func(string) ([]byte, error) { return []byte(asmSrc), nil },
+		Asm: func(filename, goarch string) ([]byte, error) {
+			gotArch = goarch
+			return []byte(asmListing), nil
+		},
+		Play: PlayOptions{Enabled: true},
+	}
+	tests := []struct {
+		cmd  string
+		arch string
+		want []string // the classes and texts of the lines
+	}{
+		{
+			".asm add.go add",
+			"",
+			[]string{
+				"asm-src:func add(a, b int) int {",
+				"asm-ins:00000  TEXT    main.add(SB), NOSPLIT|NOFRAME|ABIInternal, $0-16",
+				"asm-src hl:c := a + b",
+				"asm-ins hl:00000  LEAQ    (BX)(AX*1), CX",
+				"asm-ins hl:00004  NOP",
+				"asm-src:return c * 2",
+				"asm-ins:00004  LEAQ    (CX)(CX*1), AX",
+				"asm-ins:00008  RET",
+			},
+		},
+		{
+			".asm add.go T.Inc amd64",
+			"amd64",
+			[]string{
+				"asm-src:func (t *T) Inc() { t.n++ }",
+				"asm-ins:00000  TEXT    main.(*T).Inc(SB), NOSPLIT|NOFRAME|ABIInternal, $0-8",
+				"asm-ins:00000  INCQ    (AX)",
+				"asm-ins:00003  RET",
+			},
+		},
+	}
+	for _, tt := range tests {
+		e, err := parseAsm(ctx, "test.slide", 1, tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		if gotArch != tt.arch {
+			t.Errorf("%s: compiled for %q; want %q", tt.cmd, gotArch, tt.arch)
+		}
+		var got []string
+		for _, span := range strings.Split(string(e.(Asm).Text), "</span>\n")[:len(tt.want)] {
+			class := span[strings.Index(span, `class="`)+7:]
+			class = class[:strings.Index(class, `"`)]
+			got = append(got, class+":"+strings.TrimSpace(stripTags(span[strings.Index(span, ">")+1:])))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.cmd, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	for _, cmd := range []string{".asm add.go", ".asm add.go sub", ".asm add.go add AMD64"} {
+		if _, err := parseAsm(ctx, "test.slide", 1, cmd); err == nil {
+			t.Errorf("%s: no error", cmd)
+		}
+	}
+
+	// Nothing is compiled when the playground is off.
+	gotArch = "none"
+	ctx.Play.Enabled = false
+	e, err := parseAsm(ctx, "test.slide", 1, ".asm add.go add amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotArch != "none" || !strings.Contains(string(e.(Asm).Text), notCompiled) {
+		t.Errorf("with the playground off: compiled for %q, got %s", gotArch, e.(Asm).Text)
+	}
+}
+
+func TestAsmSteps(t *testing.T) {
+	ctx := &Context{
+		ReadFile: func(string) ([]byte, error) { return []byte(asmSrc), nil },
+		Asm:      func(string, string) ([]byte, error) { return []byte(asmListing), nil },
+		Play:     PlayOptions{Enabled: true},
+	}
+	e, err := parseAsm(ctx, "test.slide", 1, ".asm add.go add L8,L9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(e.(Asm).Text)
+	for _, want := range []string{`<pre steps="2">`, `class="asm-ins hl" steps="0">    00000`, `class="asm-ins" steps="1">    00008`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%s\ndoes not contain\n%s", text, want)
+		}
+	}
+}
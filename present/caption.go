@@ -11,12 +11,13 @@ func init() {
 }
 
 type Caption struct {
+	Pos
 	Text string
 }
 
 func (c Caption) TemplateName() string { return "caption" }
 
-func parseCaption(_ *Context, _ string, _ int, text string) (Elem, error) {
+func parseCaption(_ *Context, fileName string, lineno int, text string) (Elem, error) {
 	text = strings.TrimSpace(strings.TrimPrefix(text, ".caption"))
-	return Caption{text}, nil
+	return Caption{Pos: linePos(fileName, lineno), Text: text}, nil
 }
@@ -15,13 +15,38 @@ import (
 	"strings"
 )
 
-// Is the playground available?
-var PlayEnabled = false
+// PlayOptions control which .play snippets may be run from the browser,
+// and how they are built.
+type PlayOptions struct {
+	Enabled    bool     // whether the playground is available
+	Extensions []string // if not empty, the extensions of the files that may be run, such as ".go"
+	ReadOnly   bool     // whether runnable snippets are shown without being editable
+
+	// The context in which Go snippets are built. These are interpreted
+	// by the server that runs them.
+	Module  string   // directory of the go.mod of the module the snippets are built in, relative to the document
+	Go      string   // Go toolchain: a version such as "go1.22.0", or the path of a go command
+	GoFlags string   // GOFLAGS for building
+	Tags    []string // build tags, in addition to OMIT
+}
 
-// TOOD(adg): replace the PlayEnabled flag with something less spaghetti-like.
-// Instead this will probably be determined by a template execution Context
-// value that contains various global metadata required when rendering
-// templates.
+// Allows reports whether a snippet from a file with extension ext may be
+// run. A txtar archive holds a Go program, so it may be run if Go files
+// may.
+func (p PlayOptions) Allows(ext string) bool {
+	if !p.Enabled {
+		return false
+	}
+	if len(p.Extensions) == 0 {
+		return true
+	}
+	for _, e := range p.Extensions {
+		if e == ext || e == ".go" && ext == ".txtar" {
+			return true
+		}
+	}
+	return false
+}
 
 func init() {
 	Register("code", parseCode)
@@ -29,42 +54,63 @@ func init() {
 }
 
 type Code struct {
+	Pos
 	Text     template.HTML
 	Play     bool   // runnable code
+	PlayCmd  bool   // written with .play, whether or not it may be run
+	ReadOnly bool   // runnable code that may not be edited
 	FileName string // file name
 	Ext      string // file extension
 	Raw      []byte // content of the file
+
+	data *codeTemplateData // for rendering Text again; see linkCodeTransitions
 }
 
 func (c Code) TemplateName() string { return "code" }
 
+// Program returns the program that the playground runs for runnable code:
+// the code as shown, with the rest of the file it was taken from around it.
+// For a txtar archive, it is the whole archive.
+func (c Code) Program() string {
+	if c.data == nil {
+		return string(c.Raw)
+	}
+	return string(c.data.Prefix) + string(rawCode(c.data.Lines)) + string(c.data.Suffix)
+}
+
 // The input line is a .code or .play entry with a file name and an optional HLfoo marker on the end.
+// The marker may also be a comma-separated list of HLfoo groups and line ranges such as L12-15,
+// which are highlighted one after the other. A line range on its own is not a marker, so that
+// it may be an address, such as the name of a declaration L1.
 // Anything between the file and HL (if any) is an address expression, which we treat as a string here.
 // We pick off the HL first, for easy parsing.
+const highlightStepRE = `(?:HL[a-zA-Z0-9_]*|L[0-9]+(?:-[0-9]+)?)`
+
 var (
-	highlightRE = regexp.MustCompile(`\s+HL([a-zA-Z0-9_]+)?$`)
+	highlightRE = regexp.MustCompile(`\s+(HL[a-zA-Z0-9_]*(?:,` + highlightStepRE + `)*|` + highlightStepRE + `(?:,` + highlightStepRE + `)+)$`)
 	hlCommentRE = regexp.MustCompile(`(.+) // HL(.*)$`)
-	codeRE      = regexp.MustCompile(`\.(code|play)\s+((?:(?:-edit|-numbers)\s+)*)([^\s]+)(?:\s+(.*))?$`)
+	codeRE      = regexp.MustCompile(`\.(code|play)\s+((?:(?:-edit|-numbers|-doc|-rev=[^\s]+)\s+)*)([^\s]+)(?:\s+(.*))?$`)
+	revFlagRE   = regexp.MustCompile(`-rev=([^\s]+)`)
 )
 
 // parseCode parses a code present directive. Its syntax:
-//   .code [-numbers] [-edit] <filename> [address] [highlight]
+//   .code [-numbers] [-edit] [-doc] [-rev=<ref>] <filename> [address] [highlight]
 // The directive may also be ".play" if the snippet is executable.
 func parseCode(ctx *Context, sourceFile string, sourceLine int, cmd string) (Elem, error) {
 	cmd = strings.TrimSpace(cmd)
 
 	// Pull off the HL, if any, from the end of the input line.
-	highlight := ""
+	steps := []highlightStep{{}}
 	if hl := highlightRE.FindStringSubmatchIndex(cmd); len(hl) == 4 {
-		highlight = cmd[hl[2]:hl[3]]
-		cmd = cmd[:hl[2]-2]
+		steps = parseHighlightSteps(cmd[hl[2]:hl[3]])
+		cmd = cmd[:hl[0]]
 	}
 
 	// Parse the remaining command line.
 	// Arguments:
 	// args[0]: whole match
 	// args[1]:  .code/.play
-	// args[2]: flags ("-edit -numbers")
+	// args[2]: flags ("-edit -numbers -doc -rev=ref")
 	// args[3]: file name
 	// args[4]: optional address
 	args := codeRE.FindStringSubmatch(cmd)
@@ -72,40 +118,54 @@ func parseCode(ctx *Context, sourceFile string, sourceLine int, cmd string) (Ele
 		return nil, fmt.Errorf("%s:%d: syntax error for .code/.play invocation", sourceFile, sourceLine)
 	}
 	command, flags, file, addr := args[1], args[2], args[3], strings.TrimSpace(args[4])
-	play := command == "play" && PlayEnabled
+	play := command == "play" && ctx.Play.Allows(filepath.Ext(file))
+	edit := strings.Contains(flags, "-edit") && !(play && ctx.Play.ReadOnly)
 
 	// Read in code file and (optionally) match address.
 	filename := filepath.Join(filepath.Dir(sourceFile), file)
-	textBytes, err := ctx.ReadFile(filename)
+	textBytes, err := readCodeFile(ctx, filename, flags)
 	if err != nil {
 		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
 	}
-	lo, hi, err := addrToByteRange(addr, 0, textBytes)
+	if filepath.Ext(filename) == ".txtar" {
+		// The files of a multi-file program are shown whole, in tabs.
+		if addr != "" {
+			return nil, fmt.Errorf("%s:%d: addresses are not supported for txtar archives", sourceFile, sourceLine)
+		}
+		text, raw, err := archiveCode(textBytes, steps, edit, strings.Contains(flags, "-numbers"))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", sourceFile, sourceLine, file, err)
+		}
+		return Code{
+			Pos:      linePos(sourceFile, sourceLine),
+			Text:     text,
+			Play:     play,
+			PlayCmd:  command == "play",
+			ReadOnly: play && ctx.Play.ReadOnly,
+			FileName: filepath.Base(filename),
+			Ext:      ".txtar",
+			Raw:      raw,
+		}, nil
+	}
+	lo, hi, err := codeRange(filename, textBytes, addr, strings.Contains(flags, "-doc"))
 	if err != nil {
 		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
 	}
-
-	// Acme pattern matches can stop mid-line,
-	// so run to end of line in both directions if not at line start/end.
-	for lo > 0 && textBytes[lo-1] != '\n' {
-		lo--
-	}
-	if hi > 0 {
-		for hi < len(textBytes) && textBytes[hi-1] != '\n' {
-			hi++
-		}
-	}
-
 	lines := codeLines(textBytes, lo, hi)
 
 	data := &codeTemplateData{
-		Lines:   formatLines(lines, highlight),
-		Edit:    strings.Contains(flags, "-edit"),
+		Lines:   formatLines(lines, steps),
+		Edit:    edit,
 		Numbers: strings.Contains(flags, "-numbers"),
 	}
+	if len(steps) > 1 {
+		data.Steps = len(steps)
+	}
+	highlightLines(data.Lines, filepath.Ext(filename))
 
-	// Include before and after in a hidden span for playground code.
-	if play {
+	// Include before and after in a hidden span for playground code,
+	// which may be recorded even if it cannot be run.
+	if command == "play" {
 		data.Prefix = textBytes[:lo]
 		data.Suffix = textBytes[hi:]
 	}
@@ -115,27 +175,129 @@ func parseCode(ctx *Context, sourceFile string, sourceLine int, cmd string) (Ele
 		return nil, err
 	}
 	return Code{
+		Pos:      linePos(sourceFile, sourceLine),
 		Text:     template.HTML(buf.String()),
 		Play:     play,
+		PlayCmd:  command == "play",
+		ReadOnly: play && ctx.Play.ReadOnly,
 		FileName: filepath.Base(filename),
 		Ext:      filepath.Ext(filename),
 		Raw:      rawCode(lines),
+		data:     data,
 	}, nil
 }
 
+// codeRange returns the byte range of the full lines of src, read from
+// filename, selected by the address addr. The address may be a region,
+// a Go declaration, whose doc comment is included if withDoc is set, or
+// an acme address.
+func codeRange(filename string, src []byte, addr string, withDoc bool) (lo, hi int, err error) {
+	switch {
+	case isRegionAddr(addr):
+		lo, hi, err = regionToByteRange(addr, src)
+	case isSymbolAddr(addr):
+		lo, hi, err = symbolToByteRange(filename, src, addr, withDoc)
+	default:
+		lo, hi, err = addrToByteRange(addr, 0, src)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Acme pattern matches can stop mid-line,
+	// so run to end of line in both directions if not at line start/end.
+	for lo > 0 && src[lo-1] != '\n' {
+		lo--
+	}
+	if hi > 0 {
+		for hi < len(src) && src[hi-1] != '\n' {
+			hi++
+		}
+	}
+	return lo, hi, nil
+}
+
+// readCodeFile reads the named file, at the revision given by the -rev
+// flag if there is one.
+func readCodeFile(ctx *Context, filename, flags string) ([]byte, error) {
+	var rev string
+	if m := revFlagRE.FindStringSubmatch(flags); m != nil {
+		rev = m[1]
+	}
+	return readFileAt(ctx, filename, rev)
+}
+
+// readFileAt reads the named file at the revision rev, or from the working
+// tree if rev is empty.
+func readFileAt(ctx *Context, filename, rev string) ([]byte, error) {
+	if rev == "" {
+		return ctx.ReadFile(filename)
+	}
+	if ctx.ReadFileAt == nil {
+		return nil, fmt.Errorf("cannot read %s at revision %s: revisions are not supported", filename, rev)
+	}
+	return ctx.ReadFileAt(filename, rev)
+}
+
+// A highlightStep selects the lines highlighted together, either by the
+// group named in their "// HL[group]" comments or by a range of line numbers.
+type highlightStep struct {
+	group  string
+	lo, hi int // line numbers, if the step is a range
+}
+
+func (s highlightStep) matches(line codeLine, group string, marked bool) bool {
+	if s.lo > 0 {
+		return s.lo <= line.N && line.N <= s.hi
+	}
+	return marked && group == s.group
+}
+
+// parseHighlightSteps parses a highlight marker such as HLfoo or
+// HLparse,L12-15,HLreply. The syntax has been checked by highlightRE.
+func parseHighlightSteps(marker string) []highlightStep {
+	var steps []highlightStep
+	for _, s := range strings.Split(marker, ",") {
+		if strings.HasPrefix(s, "HL") {
+			steps = append(steps, highlightStep{group: s[2:]})
+			continue
+		}
+		r := strings.SplitN(s[1:], "-", 2)
+		lo, _ := strconv.Atoi(r[0])
+		hi := lo
+		if len(r) == 2 {
+			hi, _ = strconv.Atoi(r[1])
+		}
+		steps = append(steps, highlightStep{lo: lo, hi: hi})
+	}
+	return steps
+}
+
 // formatLines returns a new slice of codeLine with the given lines
 // replacing tabs with spaces and adding highlighting where needed.
-func formatLines(lines []codeLine, highlight string) []codeLine {
+// If there are several highlight steps, a line is highlighted at first
+// if it belongs to the first step.
+func formatLines(lines []codeLine, steps []highlightStep) []codeLine {
 	formatted := make([]codeLine, len(lines))
 	for i, line := range lines {
 		// Replace tabs with spaces, which work better in HTML.
 		line.L = strings.Replace(line.L, "\t", "    ", -1)
 
-		// Highlight lines that end with "// HL[highlight]"
+		// Highlight lines that end with "// HL[group]"
 		// and strip the magic comment.
-		if m := hlCommentRE.FindStringSubmatch(line.L); m != nil {
-			line.L = m[1]
-			line.HL = m[2] == highlight
+		var group string
+		m := hlCommentRE.FindStringSubmatch(line.L)
+		if m != nil {
+			line.L, group = m[1], m[2]
+		}
+		for j, s := range steps {
+			if !s.matches(line, group, m != nil) {
+				continue
+			}
+			if len(steps) > 1 {
+				line.Steps = append(line.Steps, j)
+			}
+			line.HL = line.HL || j == 0
 		}
 
 		formatted[i] = line
@@ -158,23 +320,31 @@ type codeTemplateData struct {
 	Lines          []codeLine
 	Prefix, Suffix []byte
 	Edit, Numbers  bool
+	Steps          int // number of highlight steps, if more than one
+
+	// Whether the code is animated from that of the previous slide,
+	// and into that of the next one.
+	TransitionIn, TransitionOut bool
 }
 
-var leadingSpaceRE = regexp.MustCompile(`^[ \t]*`)
+// codeFuncs are the functions of the templates that render code.
+var codeFuncs = template.FuncMap{
+	"join": func(steps []int) string {
+		return strings.Trim(fmt.Sprint(steps), "[]")
+	},
+}
 
-var codeTemplate = template.Must(template.New("code").Funcs(template.FuncMap{
-	"trimSpace":    strings.TrimSpace,
-	"leadingSpace": leadingSpaceRE.FindString,
-}).Parse(codeTemplateHTML))
+var codeTemplate = template.Must(template.New("code").Funcs(codeFuncs).Parse(codeTemplateHTML))
 
 const codeTemplateHTML = `
 {{with .Prefix}}<pre style="display: none"><span>{{printf "%s" .}}</span></pre>{{end}}
 
-<pre{{if .Edit}} contenteditable="true" spellcheck="false"{{end}}{{if .Numbers}} class="numbers"{{end}}>{{/*
-	*/}}{{range .Lines}}<span num="{{.N}}">{{/*
-	*/}}{{if .HL}}{{leadingSpace .L}}<b>{{trimSpace .L}}</b>{{/*
-	*/}}{{else}}{{.L}}{{end}}{{/*
-*/}}</span>
+<pre{{if .Edit}} contenteditable="true" spellcheck="false"{{end}}{{if .Numbers}} class="numbers"{{end}}{{/*
+	*/}}{{with .Steps}} steps="{{.}}"{{end}}{{/*
+	*/}}{{if .TransitionIn}} transition-in{{end}}{{if .TransitionOut}} transition-out{{end}}>{{/*
+	*/}}{{range .Lines}}<span num="{{.N}}"{{/*
+	*/}}{{with .Steps}} steps="{{join .}}"{{end}}{{/*
+	*/}}{{if and .Steps .HL}} class="hl"{{end}}{{with .From}} from="{{.}}"{{end}}>{{.H}}</span>
 {{end}}</pre>
 
 {{with .Suffix}}<pre style="display: none"><span>{{printf "%s" .}}</span></pre>{{end}}
@@ -182,9 +352,18 @@ const codeTemplateHTML = `
 
 // codeLine represents a line of code extracted from a source file.
 type codeLine struct {
-	L  string // The line of code.
-	N  int    // The line number from the source file.
-	HL bool   // Whether the line should be highlighted.
+	L  string        // The line of code.
+	N  int           // The line number from the source file.
+	HL bool          // Whether the line should be highlighted.
+	H  template.HTML // The line as syntax-highlighted HTML.
+
+	// The highlight steps the line belongs to, if there are several.
+	// The client moves the highlight from one step to the next.
+	Steps []int
+
+	// The 1-based index of the line of the previous slide's code that this
+	// line continues, or 0 if there is none; see linkCodeTransitions.
+	From int
 }
 
 // codeLines takes a source file and returns the lines that
@@ -0,0 +1,195 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const stepsSrc = `func serve() {
+	req := parse() // HLparse
+	res := exec(req) // HLexec
+	reply(res)
+	log() // HL
+}
+`
+
+func TestCodeSteps(t *testing.T) {
+	ctx := &Context{ReadFile: func(string) ([]byte, error) { return []byte(stepsSrc), nil }}
+	tests := []struct {
+		cmd   string
+		steps bool
+		want  []string
+	}{
+		{
+			".code serve.go",
+			false,
+			[]string{`<span num="5">    <b>log()</b></span>`},
+		},
+		{
+			".code serve.go HLexec",
+			false,
+			[]string{`<span num="3">    <b>res := exec(req)</b></span>`, `<span num="5">    log()</span>`},
+		},
+		{
+			".code serve.go L3,L4",
+			true,
+			[]string{`<span num="3" steps="0" class="hl">    res := exec(req)</span>`, `<span num="4" steps="1">    reply(res)</span>`},
+		},
+		{
+			".code serve.go /^func/,/^}/ HLparse,HLexec,L3-4",
+			true,
+			[]string{
+				`<pre steps="3">`,
+				`<span num="2" steps="0" class="hl">    req := parse()</span>`,
+				`<span num="3" steps="1 2">    res := exec(req)</span>`,
+				`<span num="4" steps="2">    reply(res)</span>`,
+				`<span num="5">    log()</span>`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		e, err := parseCode(ctx, "test.slide", 1, tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		// Ignore the syntax highlighting.
+		text := string(e.(Code).Text)
+		for _, class := range []string{"builtin", "keyword", "comment", "string", "number"} {
+			text = strings.Replace(text, `<span class="tok-`+class+`">`, "<span>", -1)
+		}
+		text = plainSpanRE.ReplaceAllString(text, "$1")
+		for _, w := range tt.want {
+			if !strings.Contains(text, w) {
+				t.Errorf("%s: output does not contain %s:\n%s", tt.cmd, w, text)
+			}
+		}
+		if got := strings.Contains(text, "steps="); got != tt.steps {
+			t.Errorf("%s: stepped = %v; want %v", tt.cmd, got, tt.steps)
+		}
+	}
+}
+
+var plainSpanRE = regexp.MustCompile(`<span>([^<]*)</span>`)
+
+// A trailing word that looks like a line range but stands alone is an
+// address, not a highlight.
+func TestCodeLineRangeAddress(t *testing.T) {
+	const src = "package p\n\nfunc L1() {}\n\nfunc L2() {}\n"
+	ctx := &Context{ReadFile: func(string) ([]byte, error) { return []byte(src), nil }}
+	e, err := parseCode(ctx, "test.slide", 1, ".code p.go L1")
+	if err != nil {
+		t.Fatalf("parseCode: %v", err)
+	}
+	text := string(e.(Code).Text)
+	if !strings.Contains(text, "L1") || strings.Contains(text, "L2") || strings.Contains(text, "steps=") || strings.Contains(text, "<b>") {
+		t.Errorf("got %s; want the declaration of L1, unhighlighted", text)
+	}
+}
+
+func TestCodeRev(t *testing.T) {
+	var gotName, gotRev string
+	ctx := &Context{
+		ReadFile: func(string) ([]byte, error) { return []byte("current\n"), nil },
+		ReadFileAt: func(name, rev string) ([]byte, error) {
+			gotName, gotRev = name, rev
+			return []byte("old\n"), nil
+		},
+	}
+	e, err := parseCode(ctx, "talk/test.slide", 1, ".code -numbers -rev=v1.0 main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(e.(Code).Raw); got != "old\n" {
+		t.Errorf("Raw = %q; want %q", got, "old\n")
+	}
+	if gotName != "talk/main.go" || gotRev != "v1.0" {
+		t.Errorf("ReadFileAt(%q, %q); want ReadFileAt(%q, %q)", gotName, gotRev, "talk/main.go", "v1.0")
+	}
+
+	ctx.ReadFileAt = nil
+	if _, err := parseCode(ctx, "test.slide", 1, ".code -rev=v1.0 main.go"); err == nil {
+		t.Error("-rev without ReadFileAt: got no error")
+	}
+}
+
+func TestPlayOptions(t *testing.T) {
+	tests := []struct {
+		play   PlayOptions
+		header string
+		file   string
+		want   bool // whether the snippet may be run
+		ro     bool // whether it is read-only
+	}{
+		{PlayOptions{}, "", "main.go", false, false},
+		{PlayOptions{Enabled: true}, "", "main.go", true, false},
+		{PlayOptions{Enabled: true}, "#+play=false\n", "main.go", false, false},
+		{PlayOptions{Enabled: false}, "#+play=true\n", "main.go", false, false},
+		{PlayOptions{Enabled: true, Extensions: []string{".go"}}, "", "main.go", true, false},
+		{PlayOptions{Enabled: true, Extensions: []string{".go"}}, "", "run.sh", false, false},
+		{PlayOptions{Enabled: true, ReadOnly: true}, "", "main.go", true, true},
+		{PlayOptions{Enabled: true}, "#+play=readonly\n", "main.go", true, true},
+	}
+	for _, tt := range tests {
+		ctx := &Context{
+			ReadFile: func(string) ([]byte, error) { return []byte("package main\n"), nil },
+			Play:     tt.play,
+		}
+		src := tt.header + "Title\n\nAuthor\n\n* Slide\n\n.play -edit " + tt.file + "\n"
+		doc, err := ctx.Parse(strings.NewReader(src), "test.slide", 0)
+		if err != nil {
+			t.Errorf("%+v %q: %v", tt.play, tt.header, err)
+			continue
+		}
+		c := doc.Sections[0].Elem[0].(Code)
+		if c.Play != tt.want || c.ReadOnly != tt.ro {
+			t.Errorf("%+v %q %s: got Play %v, ReadOnly %v; want %v, %v", tt.play, tt.header, tt.file, c.Play, c.ReadOnly, tt.want, tt.ro)
+		}
+		if edit := strings.Contains(string(c.Text), "contenteditable"); edit == tt.ro {
+			t.Errorf("%+v %q %s: editable %v", tt.play, tt.header, tt.file, edit)
+		}
+	}
+}
+
+func TestPlayBuildHeader(t *testing.T) {
+	ctx := &Context{Play: PlayOptions{Enabled: true, Module: "..", Tags: []string{"dir"}}}
+	const src = "#+playModule=../hello\n#+playGo=go1.22.4\n#+playGoFlags=-mod=vendor\n#+playTags=a, b\nTitle\n\nAuthor\n\n* Slide\n"
+	doc, err := ctx.Parse(strings.NewReader(src), "test.slide", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PlayOptions{Enabled: true, Module: "../hello", Go: "go1.22.4", GoFlags: "-mod=vendor", Tags: []string{"a", "b"}}
+	if !reflect.DeepEqual(doc.Play, want) {
+		t.Errorf("got %+v, want %+v", doc.Play, want)
+	}
+
+	doc, err = ctx.Parse(strings.NewReader("Title\n\nAuthor\n\n* Slide\n"), "test.slide", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Play, ctx.Play) {
+		t.Errorf("without header: got %+v, want %+v", doc.Play, ctx.Play)
+	}
+}
+
+func TestCodeProgram(t *testing.T) {
+	const src = "package main\n\n// START OMIT\nfunc main() {\n\tprintln() // HL\n}\n// END OMIT\n"
+	ctx := &Context{
+		ReadFile: func(string) ([]byte, error) { return []byte(src), nil },
+		Play:     PlayOptions{Enabled: true},
+	}
+	e, err := parseCode(ctx, "test.slide", 1, ".play main.go /START OMIT/,/END OMIT/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package main\n\nfunc main() {\n    println()\n}\n"
+	if got := e.(Code).Program(); got != want {
+		t.Errorf("Program() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register("diff", parseDiff)
+}
+
+// Diff is the difference between two files, or two revisions of a file.
+type Diff struct {
+	Pos
+	Text      template.HTML
+	Side      bool      // side by side rather than unified
+	FileNames [2]string // names of the old and new files
+}
+
+func (d Diff) TemplateName() string { return "diff" }
+
+// parseDiff parses a diff present directive. Its syntax:
+//   .diff [-numbers] [-side] [-rev=<ref>] <old file> [-rev=<ref>] <new file> [address]
+// The address selects the lines to compare in both files.
+func parseDiff(ctx *Context, sourceFile string, sourceLine int, cmd string) (Elem, error) {
+	syntaxErr := fmt.Errorf("%s:%d: syntax error for .diff invocation", sourceFile, sourceLine)
+	args := strings.Fields(cmd)[1:]
+	data := &diffTemplateData{}
+	for len(args) > 0 && (args[0] == "-numbers" || args[0] == "-side") {
+		data.Numbers = data.Numbers || args[0] == "-numbers"
+		data.Side = data.Side || args[0] == "-side"
+		args = args[1:]
+	}
+	var files, revs [2]string
+	for i := range files {
+		if len(args) > 0 && strings.HasPrefix(args[0], "-rev=") {
+			revs[i] = args[0][len("-rev="):]
+			args = args[1:]
+		}
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return nil, syntaxErr
+		}
+		files[i] = args[0]
+		args = args[1:]
+	}
+	addr := strings.Join(args, " ")
+
+	var lines [2][]codeLine
+	for i, file := range files {
+		filename := filepath.Join(filepath.Dir(sourceFile), file)
+		src, err := readFileAt(ctx, filename, revs[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+		}
+		lo, hi, err := codeRange(filename, src, addr, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", sourceFile, sourceLine, file, err)
+		}
+		lines[i] = formatLines(codeLines(src, lo, hi), []highlightStep{{}})
+		highlightLines(lines[i], filepath.Ext(filename))
+	}
+
+	var texts [2][]string
+	for i := range lines {
+		for _, l := range lines[i] {
+			texts[i] = append(texts[i], l.L)
+		}
+	}
+	ops, err := diffOps(texts[0], texts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+	}
+	if data.Side {
+		data.Old, data.New = sideBySide(ops, lines[0], lines[1])
+	} else {
+		data.Lines = unified(ops, lines[0], lines[1])
+	}
+
+	var buf bytes.Buffer
+	if err := diffTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return Diff{
+		Pos:       linePos(sourceFile, sourceLine),
+		Text:      template.HTML(buf.String()),
+		Side:      data.Side,
+		FileNames: [2]string{filepath.Base(files[0]), filepath.Base(files[1])},
+	}, nil
+}
+
+// A diffLine is a line of code shown in a diff, numbered as in the file it
+// is from. Its class marks it as added, deleted, or a filler that keeps the
+// sides of a side by side diff aligned; lines common to both files have no
+// class.
+type diffLine struct {
+	codeLine
+	Class string
+}
+
+// unified returns the lines of a unified diff of the lines a and b.
+func unified(ops []diffOp, a, b []codeLine) []diffLine {
+	var out []diffLine
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			out = append(out, diffLine{codeLine: b[op.b]})
+		case diffDelete:
+			out = append(out, diffLine{a[op.a], "diff-del"})
+		case diffInsert:
+			out = append(out, diffLine{b[op.b], "diff-add"})
+		}
+	}
+	return out
+}
+
+// sideBySide returns the two columns of a side by side diff of the lines
+// a and b. Deleted and inserted lines are paired up, and fillers pad the
+// shorter of the two columns.
+func sideBySide(ops []diffOp, a, b []codeLine) (left, right []diffLine) {
+	pad := func() {
+		for len(left) < len(right) {
+			left = append(left, diffLine{Class: "diff-empty"})
+		}
+		for len(right) < len(left) {
+			right = append(right, diffLine{Class: "diff-empty"})
+		}
+	}
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			pad()
+			left = append(left, diffLine{codeLine: a[op.a]})
+			right = append(right, diffLine{codeLine: b[op.b]})
+		case diffDelete:
+			left = append(left, diffLine{a[op.a], "diff-del"})
+		case diffInsert:
+			right = append(right, diffLine{b[op.b], "diff-add"})
+		}
+	}
+	pad()
+	return left, right
+}
+
+type diffTemplateData struct {
+	Lines         []diffLine // unified diff
+	Old, New      []diffLine // side by side diff
+	Numbers, Side bool
+}
+
+var diffTemplate = template.Must(template.New("diff").Parse(diffTemplateHTML))
+
+const diffTemplateHTML = `{{define "lines"}}{{/*
+*/}}{{range .}}<span{{with .N}} num="{{.}}"{{end}}{{with .Class}} class="{{.}}"{{end}}>{{.H}}</span>
+{{end}}{{end}}{{/*
+
+*/}}{{if .Side}}{{/*
+*/}}<pre{{if .Numbers}} class="numbers"{{end}}>{{template "lines" .Old}}</pre>{{/*
+*/}}<pre{{if .Numbers}} class="numbers"{{end}}>{{template "lines" .New}}</pre>{{/*
+*/}}{{else}}{{/*
+*/}}<pre{{if .Numbers}} class="numbers"{{end}}>{{template "lines" .Lines}}</pre>{{/*
+*/}}{{end}}
+`
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// A diffOp is a step in turning one list of lines into another: a line of
+// a kept, a line of a deleted, or a line of b inserted.
+type diffOp struct {
+	kind diffKind
+	a, b int // indexes of the lines in a and b
+}
+
+// maxDiffCells bounds the size of the table that diffOps fills in, the
+// product of the numbers of lines that differ between the two lists.
+const maxDiffCells = 1 << 22
+
+// diffOps returns the steps that turn a into b, keeping the lines of their
+// longest common subsequence. Deletions come before insertions. Lists that
+// differ in too many lines are reported as an error.
+func diffOps(a, b []string) ([]diffOp, error) {
+	// Lines common to the start and end of both lists are kept as they are.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	n, m := len(a)-pre-suf, len(b)-pre-suf
+	if n*m > maxDiffCells {
+		return nil, fmt.Errorf("too many differences to compare (%d lines and %d lines)", n, m)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[pre+i:pre+n] and b[pre+j:pre+m].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[pre+i] == b[pre+j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	for k := 0; k < pre; k++ {
+		ops = append(ops, diffOp{diffEqual, k, k})
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[pre+i] == b[pre+j]:
+			ops = append(ops, diffOp{diffEqual, pre + i, pre + j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, pre + i, pre + j})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, pre + i, pre + j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, pre + i, pre + j})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, pre + i, pre + j})
+	}
+	for k := 0; k < suf; k++ {
+		ops = append(ops, diffOp{diffEqual, pre + n + k, pre + m + k})
+	}
+	return ops, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	files := map[string]string{
+		"old.txt": "a\nb\nc\n",
+		"new.txt": "a\nB\nc\nd\n",
+	}
+	ctx := &Context{
+		ReadFile: func(name string) ([]byte, error) { return []byte(files[name]), nil },
+		ReadFileAt: func(name, rev string) ([]byte, error) {
+			return []byte(files[rev+".txt"]), nil
+		},
+	}
+	tests := []struct {
+		cmd  string
+		side bool
+		want string
+	}{
+		{
+			".diff old.txt new.txt",
+			false,
+			`<pre><span num="1">a</span>
+<span num="2" class="diff-del">b</span>
+<span num="2" class="diff-add">B</span>
+<span num="3">c</span>
+<span num="4" class="diff-add">d</span>
+</pre>`,
+		},
+		{
+			".diff -numbers -rev=old x.txt -rev=new x.txt 2,3",
+			false,
+			`<pre class="numbers"><span num="2" class="diff-del">b</span>
+<span num="2" class="diff-add">B</span>
+<span num="3">c</span>
+</pre>`,
+		},
+		{
+			".diff -side old.txt new.txt",
+			true,
+			`<pre><span num="1">a</span>
+<span num="2" class="diff-del">b</span>
+<span num="3">c</span>
+<span class="diff-empty"></span>
+</pre><pre><span num="1">a</span>
+<span num="2" class="diff-add">B</span>
+<span num="3">c</span>
+<span num="4" class="diff-add">d</span>
+</pre>`,
+		},
+	}
+	for _, tt := range tests {
+		e, err := parseDiff(ctx, "test.slide", 1, tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		d := e.(Diff)
+		if got := strings.TrimSpace(string(d.Text)); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.cmd, got, tt.want)
+		}
+		if d.Side != tt.side {
+			t.Errorf("%s: Side = %v; want %v", tt.cmd, d.Side, tt.side)
+		}
+	}
+
+	for _, cmd := range []string{".diff old.txt", ".diff -side", ".diff -rev=old"} {
+		if _, err := parseDiff(ctx, "test.slide", 1, cmd); err == nil {
+			t.Errorf("%s: got no error", cmd)
+		}
+	}
+}
+
+func TestDiffOpsSize(t *testing.T) {
+	lines := func(prefix string, n int) []string {
+		s := make([]string, n)
+		for i := range s {
+			s[i] = prefix + strconv.Itoa(i)
+		}
+		return s
+	}
+
+	// Long lists that differ in a few lines are compared.
+	a := lines("a", 100000)
+	b := append([]string{}, a...)
+	b[50000] = "changed"
+	ops, err := diffOps(a, b)
+	if err != nil {
+		t.Fatalf("diffOps of lists with one change: %v", err)
+	}
+	if len(ops) != len(a)+1 || ops[50000] != (diffOp{diffDelete, 50000, 50000}) || ops[50001] != (diffOp{diffInsert, 50001, 50000}) {
+		t.Errorf("diffOps of lists with one change: got %d ops, %v around the change", len(ops), ops[49999:50003])
+	}
+
+	// Lists that differ throughout are not.
+	if _, err := diffOps(lines("a", 5000), lines("b", 5000)); err == nil {
+		t.Errorf("diffOps of lists with no common lines: got no error")
+	}
+}
@@ -71,6 +71,45 @@ not.
 
 Lines starting with # in column 1 are commentary.
 
+Variables:
+
+A header comment of the form #+var name=value defines a variable, and
+{{name}} anywhere in the rest of the document (titles, text, lists and
+the arguments of invocations) is replaced by its value. References to
+undefined variables, and those in preformatted text, are left as they
+are. Default values may also be supplied by the parsing Context.
+
+	#+var event=GopherCon
+	#+var repo=github.com/example/project
+
+	* Welcome to {{event}}
+
+	.link https://{{repo}}
+
+Builds:
+
+A comment line reading #+build immediately before an element marks it
+to be revealed incrementally when presented as a slide. The items of a
+built list are revealed one at a time; any other element is revealed as
+a whole. Being a comment, the directive is ignored by Go Present.
+
+	#+build
+	- first point
+	- second point
+
+Code transitions:
+
+The header comment
+	#+codeTransitions=true
+animates the code on a slide from the code on the slide before it:
+lines kept from the previous slide's code move into place, added
+lines fade in and removed lines fade out. Only the first .code or
+.play element of each slide takes part, only when it shows the same
+file as that of the slide before, and only when advancing. Regions or
+revisions of one file on consecutive slides show how it changes:
+	.code server.go region=v1
+	.code -rev=v2.0 server.go
+
 Fonts:
 
 Within the input for plain text or lists, text bracketed by font
@@ -131,6 +170,15 @@ to find snippets like this
 and see only this:
 	interesting_code = fascinating_function()
 
+Such snippets may be given names and addressed as regions. This
+	.code test.go region=setup
+shows the lines between
+	// START setup OMIT
+and
+	// END setup OMIT
+Regions may nest, and the markers of any inner regions are omitted
+like other OMIT lines.
+
 Also, inside the displayed text a line that ends
 	// HL
 will be highlighted in the display; the 'h' key in the browser will
@@ -140,12 +188,33 @@ may have a suffix word, such as
 Such highlights are enabled only if the code invocation ends with
 "HL" followed by the word:
 	.code test.go /^type Foo/,/^}/ HLxxx
+A walkthrough of the code may be given as a comma-separated list of
+highlight groups and ranges of line numbers in the file:
+	.code server.go Server.ServeHTTP HLparse,HLexec,L40-42
+A range of lines on its own, such as L40, is taken as an address.
+The lines of the first step are highlighted at first, and the
+highlight moves on to the next step each time the presenter advances,
+before the slide changes.
 
 The .code function may take one or more flags immediately preceding
 the filename. This command shows test.go in an editable text area:
 	.code -edit test.go
 This command shows test.go with line numbers:
 	.code -numbers test.go
+This command shows test.go as it was at the revision v1.0 of the
+repository that holds it, if the parsing Context can read revisions:
+	.code -rev=v1.0 test.go
+
+In a Go file, the address may instead name a declaration, so that
+the snippet follows the code when it moves. A method is named by its
+receiver type, and the kind of declaration may be given to
+disambiguate:
+	.code server.go Server.ServeHTTP
+	.code config.go type:Config
+The -doc flag includes the declaration's doc comment:
+	.code -doc config.go NewConfig
+A declaration in a parenthesised group is shown on its own. OMIT
+lines and HL marks work as they do with other addresses.
 
 play:
 
@@ -154,6 +223,96 @@ on the displayed source so the program can be run from the browser.
 Although only the selected text is shown, all the source is included
 in the HTML output so it can be presented to the compiler.
 
+Whether snippets can be run is decided by the parsing Context. A
+document may turn the playground off, or show its runnable snippets
+without letting them be edited, with the header comments
+	#+play=false
+	#+play=readonly
+
+The server that runs the snippets also reads how to build them from the
+header: the root of a module to build them in, so they may import its
+packages, the Go toolchain, as a version or the path of a go command,
+GOFLAGS, and build tags, added to OMIT:
+	#+playModule=..
+	#+playGo=go1.22.4
+	#+playGoFlags=-mod=vendor
+	#+playTags=integration,linux
+Paths are relative to the document. A txtar archive with a go.mod is
+built as a module of its own. The server may limit the toolchains that
+documents can choose.
+
+A program of several files, or a whole module with packages and tests,
+may be given as a txtar archive:
+	.play -numbers hello.txtar
+where hello.txtar holds
+	-- go.mod --
+	module example.com/hello
+	-- main.go --
+	package main
+	...
+	-- greet/greet.go --
+	package greet
+	...
+Each file of the archive is shown in a tab of its own, highlighted for
+its extension; HL marks and steps apply to every file, but addresses
+are not supported. The local playground builds the module and runs the
+package at its root, or runs the tests of all its packages if it has
+any test files.
+
+diff:
+
+The function "diff" shows the differences between two files, or two
+revisions of a file, as a unified diff in which added and deleted
+lines are marked. The optional address selects the lines to compare
+in both files, and the -numbers flag shows their line numbers:
+	.diff old.go new.go
+	.diff -numbers -rev=v1.0 server.go -rev=v2.0 server.go Server.ServeHTTP
+The -side flag shows the files side by side instead:
+	.diff -side old.go new.go
+
+godoc:
+
+The function "godoc" shows the documentation of a Go package, or of one
+of its symbols, as read from its source: the declaration, highlighted
+and with the names of types linked to their documentation, then the doc
+comment.
+	.godoc ./server
+	.godoc ./server Config
+	.godoc ./server Server.ServeHTTP
+The directory of the package is relative to the present file. Test
+files and files excluded by build constraints are left out. The files
+are read through the parsing Context, which must supply ReadDir.
+
+asm:
+
+The function "asm" shows the assembly that the Go compiler generates for
+a function of a file, each line of source followed by the instructions
+generated from it. An architecture may be given after the function:
+	.asm add.go add
+	.asm -numbers vec.go Vector.Dot arm64
+Source lines marked with // HL are highlighted with their instructions,
+and the highlight steps of .code may be given at the end, to step
+through the function:
+	.asm add.go add L8,L9
+The file is compiled on its own, so it must not need other files of its
+package. Whether it can be compiled is up to the program that parses the
+file, which supplies Context.Asm; nothing is compiled if the playground
+is turned off.
+
+output:
+
+The function "output" runs a command in the directory of the present
+file when it is parsed, and shows what the command writes, like
+preformatted text:
+	.output go vet ./...
+	.output go test -bench=. -run=^$
+	.output -file bench.sh
+The command is split at spaces and run without a shell; a script given
+with -file is run with sh. Which commands and scripts may run is up to
+the program that parses the file, which supplies Context.Output. Like
+snippets, commands are not run if the playground is turned off, by the
+Context or by the header comment #+play=false.
+
 link:
 
 Create a hyperlink. The syntax is 1 or 2 space-separated arguments.
@@ -192,6 +351,21 @@ iframe:
 The function "iframe" injects iframes (pages inside pages).
 Its syntax is the same as that of image.
 
+include:
+
+The include directive splices the sections of another present file into
+the document. It stands in place of a section heading, and the top-level
+sections of the included file become sections at the current level. The
+file name is relative to the including file, and an optional range selects
+which of its top-level sections to include. If the included file has a
+header and authors, they are ignored.
+
+	.include common/about.slide
+	.include common/about.slide 2-4
+
+An include directive ends the section it appears in. Include cycles are
+reported as errors. Go Present does not support this directive.
+
 html:
 
 The function html includes the contents of the specified file as
@@ -0,0 +1,165 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format writes the document to w in present syntax. It is intended for
+// documents parsed in Source mode, whose comments and invocations it writes
+// back; Present-Plus settings such as the theme are written through the
+// header comments they were parsed from.
+//
+// The output is normalised: the header lines are written in a fixed order,
+// elements are separated by single blank lines, bullets are written as
+// "- item", preformatted blocks are indented by one tab and the arguments
+// of invocations are separated by single spaces.
+func (d *Doc) Format(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	for _, c := range d.Comments {
+		fmt.Fprintln(b, c)
+	}
+	if len(d.Comments) > 0 {
+		fmt.Fprintln(b)
+	}
+
+	// Header.
+	fmt.Fprintln(b, d.Title)
+	if d.Subtitle != "" {
+		fmt.Fprintln(b, d.Subtitle)
+	}
+	if !d.Time.IsZero() {
+		fmt.Fprintln(b, formatTime(d.Time))
+	}
+	if len(d.Tags) > 0 {
+		fmt.Fprintln(b, "Tags:", strings.Join(d.Tags, ", "))
+	}
+
+	// Authors. Extra blank lines between authors parse as empty authors,
+	// which are dropped.
+	for _, a := range d.Authors {
+		if isEmptyAuthor(a) {
+			continue
+		}
+		fmt.Fprintln(b)
+		for _, e := range a.Elem {
+			switch e := e.(type) {
+			case Text:
+				for _, l := range e.Lines {
+					if l != "" {
+						fmt.Fprintln(b, l)
+					}
+				}
+			case Link:
+				if e.Label != "" {
+					fmt.Fprintln(b, e.Label)
+				} else {
+					fmt.Fprintln(b, e.URL)
+				}
+			default:
+				return fmt.Errorf("cannot format author element of type %T", e)
+			}
+		}
+	}
+
+	// Sections.
+	for _, s := range d.Sections {
+		fmt.Fprintln(b)
+		if err := formatSection(b, s); err != nil {
+			return err
+		}
+	}
+	return b.Flush()
+}
+
+func isEmptyAuthor(a Author) bool {
+	for _, e := range a.Elem {
+		if t, ok := e.(Text); !ok || strings.Join(t.Lines, "") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTime formats t as it is accepted by parseTime. Times of 11am UTC
+// are written as a date alone, which parseTime reads back as the same time.
+func formatTime(t time.Time) string {
+	if t.Hour() == 11 && t.Minute() == 0 && t.Location() == time.UTC {
+		return t.Format("2 Jan 2006")
+	}
+	return t.Format("15:04 2 Jan 2006")
+}
+
+func formatSection(w io.Writer, s Section) error {
+	for _, c := range s.Comments {
+		fmt.Fprintln(w, c)
+	}
+	if !s.include {
+		fmt.Fprintln(w, strings.Repeat("*", len(s.Number)), strings.TrimRight(s.Title, " \t"))
+	}
+	for i, e := range s.Elem {
+		switch {
+		case i == 0 && s.include:
+		case i > 0 && isComment(s.Elem[i-1]):
+			// Comments are written directly above the element they precede.
+		default:
+			fmt.Fprintln(w)
+		}
+		if err := formatElem(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isComment(e Elem) bool {
+	_, ok := e.(Comment)
+	return ok
+}
+
+func formatElem(w io.Writer, e Elem) error {
+	switch e := e.(type) {
+	case Section:
+		return formatSection(w, e)
+	case Build:
+		fmt.Fprintln(w, "#+build")
+		return formatElem(w, e.Elem)
+	case Comment:
+		for _, l := range e.Lines {
+			fmt.Fprintln(w, l)
+		}
+	case Invocation:
+		fmt.Fprintln(w, strings.Join(append([]string{e.Name}, e.Args...), " "))
+	case List:
+		for _, l := range e.Bullet {
+			fmt.Fprintln(w, "-", strings.TrimSpace(l))
+		}
+	case Text:
+		if e.Pre {
+			for _, l := range strings.Split(strings.Join(e.Lines, "\n"), "\n") {
+				if l = strings.TrimRight(l, " \t"); l != "" {
+					l = "\t" + l
+				}
+				fmt.Fprintln(w, l)
+			}
+			break
+		}
+		for _, l := range e.Lines {
+			if strings.HasPrefix(l, ".") {
+				l = `\` + l // Escape initial period.
+			}
+			fmt.Fprintln(w, strings.TrimRight(l, " \t"))
+		}
+	default:
+		p := Position(e)
+		return fmt.Errorf("%s:%d: cannot format element of type %T", p.File, p.Line, e)
+	}
+	return nil
+}
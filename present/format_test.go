@@ -0,0 +1,119 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const formatInput = `# A leading comment.
+#+theme=white
+#+var event=GopherCon
+
+
+Title
+Tags:  go,present
+A talk at {{event}}
+10:30 7 Mar 2015
+
+Author
+@twitter
+
+
+.include common.slide
+
+# Before the first slide.
+* Slide one
+Some text
+\.not a command
+.code   -numbers  x.go   /^func/,/^}/   HLx
+-   spaced bullet
+- bullet
+
+
+
+      indented
+        more
+  
+      pre
+# trailing comment
+* Slide two
+
+#+build
+# about the list
+- a
+
+** Sub
+.caption   Hello   there
+
+# end of file
+`
+
+const formatOutput = `# A leading comment.
+#+theme=white
+#+var event=GopherCon
+
+Title
+A talk at {{event}}
+10:30 7 Mar 2015
+Tags: go, present
+
+Author
+@twitter
+
+.include common.slide
+
+# Before the first slide.
+* Slide one
+
+Some text
+\.not a command
+
+.code -numbers x.go /^func/,/^}/ HLx
+
+- spaced bullet
+- bullet
+
+	indented
+	  more
+
+	pre
+
+# trailing comment
+* Slide two
+
+# about the list
+#+build
+- a
+
+** Sub
+
+.caption Hello there
+
+# end of file
+`
+
+func format(t *testing.T, src string) string {
+	doc, err := Parse(strings.NewReader(src), "test.slide", Source)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var b bytes.Buffer
+	if err := doc.Format(&b); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	return b.String()
+}
+
+func TestFormat(t *testing.T) {
+	if got := format(t, formatInput); got != formatOutput {
+		t.Errorf("got:\n%s\nwant:\n%s", got, formatOutput)
+	}
+	if got := format(t, formatOutput); got != formatOutput {
+		t.Errorf("formatting is not idempotent; got:\n%s", got)
+	}
+}
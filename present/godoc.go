@@ -0,0 +1,289 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"html"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("godoc", parseGodoc)
+}
+
+// godocURL is where the documentation of packages is linked to.
+const godocURL = "https://pkg.go.dev"
+
+// Godoc is the documentation of a Go package or of one of its symbols,
+// read from its source.
+type Godoc struct {
+	Pos
+	Package string        // the package's directory, as written
+	Symbol  string        // the symbol, or empty for the package
+	Decl    template.HTML // the declaration, highlighted and linked
+	Doc     template.HTML // the doc comment
+}
+
+func (g Godoc) TemplateName() string { return "godoc" }
+
+// parseGodoc parses a godoc present directive. Its syntax:
+//   .godoc <dir> [symbol]
+// The directory of the package is relative to the present file. The symbol
+// is the name of a constant, variable, function or type, or a method such
+// as Server.ServeHTTP; without one, the package's documentation is shown.
+func parseGodoc(ctx *Context, sourceFile string, sourceLine int, cmd string) (Elem, error) {
+	args := strings.Fields(cmd)[1:]
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("%s:%d: syntax error for .godoc invocation", sourceFile, sourceLine)
+	}
+	g := Godoc{Pos: linePos(sourceFile, sourceLine), Package: args[0]}
+	if len(args) == 2 {
+		g.Symbol = args[1]
+	}
+	dir := filepath.Join(filepath.Dir(sourceFile), filepath.FromSlash(g.Package))
+	fset, files, pkg, err := readPackageDoc(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+	}
+	docText, decl, err := findSymbol(pkg, g.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %s: %v", sourceFile, sourceLine, g.Package, err)
+	}
+
+	var src string
+	var file *ast.File
+	if decl == nil {
+		src = "package " + pkg.Name
+	} else {
+		for _, f := range files {
+			if f.Pos() <= decl.Pos() && decl.Pos() < f.End() {
+				file = f
+			}
+		}
+		var buf bytes.Buffer
+		node := interface{}(decl)
+		if file != nil {
+			node = &printer.CommentedNode{Node: decl, Comments: file.Comments}
+		}
+		if err := (&printer.Config{Mode: printer.UseSpaces, Tabwidth: 4}).Fprint(&buf, fset, node); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+		}
+		src = buf.String()
+	}
+	g.Decl = linkDecl(src, pkg, file)
+
+	p := pkg.Printer()
+	p.DocLinkURL = func(link *comment.DocLink) string {
+		l := *link
+		if l.ImportPath == "" {
+			l.ImportPath = pkg.ImportPath
+		}
+		return l.DefaultURL(godocURL)
+	}
+	g.Doc = template.HTML(p.HTML(pkg.Parser().Parse(docText)))
+	return g, nil
+}
+
+// readPackageDoc reads the documentation of the package in dir, from the
+// files that the default build context would build, leaving out tests. The
+// files are found and read through ctx.
+func readPackageDoc(ctx *Context, dir string) (*token.FileSet, []*ast.File, *doc.Package, error) {
+	if ctx.ReadDir == nil {
+		return nil, nil, nil, fmt.Errorf("cannot read %s: reading directories is not supported", dir)
+	}
+	bc := build.Default
+	bc.ReadDir = ctx.ReadDir
+	bc.OpenFile = func(name string) (io.ReadCloser, error) {
+		b, err := ctx.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	bc.IsDir = func(name string) bool {
+		_, err := ctx.ReadDir(name)
+		return err == nil
+	}
+	bp, err := bc.ImportDir(dir, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		filename := filepath.Join(dir, name)
+		src, err := ctx.ReadFile(filename)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, f)
+	}
+	pkg, err := doc.NewFromFiles(fset, files, importPath(ctx, dir))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return fset, files, pkg, nil
+}
+
+// importPath returns the import path of the package in dir, from the
+// go.mod file of the module that holds it, or "" if it is in none.
+func importPath(ctx *Context, dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if mod, err := ctx.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			rel, err := filepath.Rel(d, abs)
+			if err != nil {
+				return ""
+			}
+			return path.Join(modulePath(mod), filepath.ToSlash(rel))
+		}
+		if filepath.Dir(d) == d {
+			return ""
+		}
+	}
+}
+
+// modulePath returns the module path declared in a go.mod file.
+func modulePath(mod []byte) string {
+	for _, line := range strings.Split(string(mod), "\n") {
+		f := strings.Fields(line)
+		if len(f) >= 2 && f[0] == "module" {
+			if p, err := strconv.Unquote(f[1]); err == nil {
+				return p
+			}
+			return f[1]
+		}
+	}
+	return ""
+}
+
+// findSymbol returns the doc comment and declaration of the named symbol of
+// pkg, or the package's doc comment if the name is empty.
+func findSymbol(pkg *doc.Package, name string) (string, ast.Decl, error) {
+	if name == "" {
+		return pkg.Doc, nil, nil
+	}
+	typ, method := name, ""
+	if i := strings.Index(name, "."); i >= 0 {
+		typ, method = name[:i], name[i+1:]
+	}
+	findValue := func(values []*doc.Value) *doc.Value {
+		for _, v := range values {
+			for _, n := range v.Names {
+				if n == name {
+					return v
+				}
+			}
+		}
+		return nil
+	}
+	findFunc := func(funcs []*doc.Func, name string) *doc.Func {
+		for _, f := range funcs {
+			if f.Name == name {
+				return f
+			}
+		}
+		return nil
+	}
+
+	for _, t := range pkg.Types {
+		if method != "" {
+			if t.Name != typ {
+				continue
+			}
+			if f := findFunc(t.Methods, method); f != nil {
+				return f.Doc, f.Decl, nil
+			}
+			return "", nil, fmt.Errorf("type %s has no method %s", typ, method)
+		}
+		if t.Name == name {
+			return t.Doc, t.Decl, nil
+		}
+		// Constructors and typed constants are listed with their type.
+		if f := findFunc(t.Funcs, name); f != nil {
+			return f.Doc, f.Decl, nil
+		}
+		if v := findValue(append(t.Consts, t.Vars...)); v != nil {
+			return v.Doc, v.Decl, nil
+		}
+	}
+	if method == "" {
+		if f := findFunc(pkg.Funcs, name); f != nil {
+			return f.Doc, f.Decl, nil
+		}
+		if v := findValue(append(pkg.Consts, pkg.Vars...)); v != nil {
+			return v.Doc, v.Decl, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no symbol %s", name)
+}
+
+// linkDecl returns the declaration src, from file of pkg, as highlighted
+// HTML in which the names of the package's symbols, and of the symbols of
+// the packages the file imports, link to their documentation.
+func linkDecl(src string, pkg *doc.Package, file *ast.File) template.HTML {
+	local := make(map[string]bool)
+	for _, t := range pkg.Types {
+		local[t.Name] = true
+	}
+	imports := make(map[string]string) // import path by package name
+	if file != nil {
+		for _, spec := range file.Imports {
+			p, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := path.Base(p)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = p
+		}
+	}
+	link := func(importPath, name, text string) string {
+		return `<a href="` + html.EscapeString(godocURL+"/"+importPath+"#"+name) + `">` + html.EscapeString(text) + `</a>`
+	}
+
+	var buf bytes.Buffer
+	toks := lexGo(src)
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		switch {
+		case tok.Class != "":
+			buf.WriteString(`<span class="tok-` + tok.Class + `">` + html.EscapeString(tok.Text) + `</span>`)
+		case imports[tok.Text] != "" && i+2 < len(toks) && toks[i+1].Text == "." && token.IsExported(toks[i+2].Text):
+			// A qualified identifier, such as http.Handler.
+			buf.WriteString(link(imports[tok.Text], toks[i+2].Text, tok.Text+"."+toks[i+2].Text))
+			i += 2
+		case local[tok.Text] && pkg.ImportPath != "":
+			buf.WriteString(link(pkg.ImportPath, tok.Text, tok.Text))
+		default:
+			buf.WriteString(html.EscapeString(tok.Text))
+		}
+	}
+	return template.HTML(buf.String())
+}
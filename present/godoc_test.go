@@ -0,0 +1,141 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGodoc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod": "module example.com/m\n",
+		"server/server.go": `// Package server serves things.
+package server
+
+import "net/http"
+
+// Config configures a [Server].
+type Config struct {
+	Addr    string       // address to listen on
+	Handler http.Handler // handler to invoke
+}
+
+// Server serves HTTP.
+type Server struct{ c Config }
+
+// NewServer returns a server.
+func NewServer(c Config) *Server { return &Server{c} }
+
+// ServeHTTP serves r.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+`,
+		"server/server_test.go": "package server\n\nfunc TestOnly() {}\n",
+		"talk.slide":            "",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := &Context{ReadFile: ioutil.ReadFile, ReadDir: ioutil.ReadDir}
+	slide := filepath.Join(dir, "talk.slide")
+
+	tests := []struct {
+		cmd  string
+		decl []string // substrings of the declaration
+		doc  string   // substring of the doc comment
+	}{
+		{".godoc ./server", []string{`<span class="tok-keyword">package</span> server`}, "serves things"},
+		{
+			".godoc ./server Config",
+			[]string{
+				`<span class="tok-keyword">type</span> <a href="https://pkg.go.dev/example.com/m/server#Config">Config</a>`,
+				`<a href="https://pkg.go.dev/net/http#Handler">http.Handler</a>`,
+				`<span class="tok-comment">// address to listen on</span>`,
+			},
+			`<a href="https://pkg.go.dev/example.com/m/server#Server">Server</a>`,
+		},
+		{".godoc ./server NewServer", []string{`NewServer(c <a href="https://pkg.go.dev/example.com/m/server#Config">Config</a>)`}, "returns a server"},
+		{".godoc ./server Server.ServeHTTP", []string{"ServeHTTP(w"}, "serves r"},
+	}
+	for _, tt := range tests {
+		e, err := parseGodoc(ctx, slide, 1, tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		g := e.(Godoc)
+		for _, want := range tt.decl {
+			if !strings.Contains(string(g.Decl), want) {
+				t.Errorf("%s: declaration\n%s\ndoes not contain\n%s", tt.cmd, g.Decl, want)
+			}
+		}
+		if !strings.Contains(string(g.Doc), tt.doc) {
+			t.Errorf("%s: doc\n%s\ndoes not contain\n%s", tt.cmd, g.Doc, tt.doc)
+		}
+	}
+
+	for _, cmd := range []string{".godoc", ".godoc ./server Missing", ".godoc ./server Server.Missing", ".godoc ./server TestOnly", ".godoc ./none"} {
+		if _, err := parseGodoc(ctx, slide, 1, cmd); err == nil {
+			t.Errorf("%s: no error", cmd)
+		}
+	}
+	if _, err := parseGodoc(&Context{ReadFile: ioutil.ReadFile}, slide, 1, ".godoc ./server"); err == nil {
+		t.Errorf("without Context.ReadDir: no error")
+	}
+
+	// The package is found and read through the Context, not the file
+	// system.
+	mem := fstest.MapFS{"mem.go": {Data: []byte("// Package mem is held in memory.\npackage mem\n")}}
+	memDir := filepath.Join(dir, "mem")
+	ctx = &Context{
+		ReadFile: func(name string) ([]byte, error) {
+			if filepath.Dir(name) == memDir {
+				return fs.ReadFile(mem, filepath.Base(name))
+			}
+			return ioutil.ReadFile(name)
+		},
+		ReadDir: func(name string) ([]os.FileInfo, error) {
+			if name != memDir {
+				return ioutil.ReadDir(name)
+			}
+			entries, err := fs.ReadDir(mem, ".")
+			if err != nil {
+				return nil, err
+			}
+			var fis []os.FileInfo
+			for _, e := range entries {
+				fi, err := e.Info()
+				if err != nil {
+					return nil, err
+				}
+				fis = append(fis, fi)
+			}
+			return fis, nil
+		},
+	}
+	e, err := parseGodoc(ctx, slide, 1, ".godoc ./mem")
+	if err != nil {
+		t.Fatalf(".godoc ./mem: %v", err)
+	}
+	if g := e.(Godoc); !strings.Contains(string(g.Doc), "held in memory") {
+		t.Errorf(".godoc ./mem: doc %s", g.Doc)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+)
+
+// A Token is a span of source code and its kind. The Class is used as the
+// CSS class of the span, prefixed with "tok-"; an empty Class denotes plain
+// text.
+type Token struct {
+	Class string
+	Text  string
+}
+
+// Token classes emitted by the built-in lexers.
+const (
+	ClassKeyword = "keyword"
+	ClassComment = "comment"
+	ClassString  = "string"
+	ClassNumber  = "number"
+	ClassBuiltin = "builtin"
+)
+
+// A LexFunc splits source code into tokens for highlighting. The texts of
+// the returned tokens, concatenated, must equal src.
+type LexFunc func(src string) []Token
+
+var lexers = make(map[string]LexFunc)
+
+// RegisterLexer binds the file extension, such as ".go", to the lexer used to
+// highlight code read from files with that extension.
+func RegisterLexer(ext string, lexer LexFunc) {
+	if len(ext) < 2 || ext[0] != '.' {
+		panic("bad extension in RegisterLexer: " + ext)
+	}
+	lexers[ext] = lexer
+}
+
+func init() {
+	RegisterLexer(".go", lexGo)
+
+	cLike := &simpleLexer{
+		lineComment:  "//",
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+	}
+	c := cLike.with("auto break case char const continue default do double else enum extern float for goto if inline int long register return short signed sizeof static struct switch typedef union unsigned void volatile while")
+	RegisterLexer(".c", c)
+	RegisterLexer(".h", c)
+	RegisterLexer(".java", cLike.with("abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long native new package private protected public return short static super switch synchronized this throw throws try void volatile while true false null"))
+	js := &simpleLexer{lineComment: "//", blockComment: [2]string{"/*", "*/"}, quotes: "\"'`"}
+	RegisterLexer(".js", js.with("async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new of return super switch this throw try typeof var void while yield true false null undefined"))
+	py := &simpleLexer{lineComment: "#", quotes: `"'`}
+	RegisterLexer(".py", py.with("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield True False None"))
+	sh := &simpleLexer{lineComment: "#", quotes: `"'`}
+	RegisterLexer(".sh", sh.with("case do done elif else esac exit export fi for function if in local return then until while"))
+	sql := &simpleLexer{lineComment: "--", blockComment: [2]string{"/*", "*/"}, quotes: `'"`, caseless: true}
+	RegisterLexer(".sql", sql.with("add all alter and as asc by create delete desc distinct drop from group having in index insert into is join key left limit not null on or order primary references right select set table union update values where"))
+}
+
+// highlight returns the HTML for each line of src, split into lines, with
+// the tokens produced by the lexer for ext wrapped in classed spans.
+// If there is no lexer for ext, the lines are only HTML-escaped.
+func highlight(src, ext string) []template.HTML {
+	lex := lexers[ext]
+	if lex == nil {
+		lex = func(src string) []Token { return []Token{{Text: src}} }
+	}
+	var (
+		out  []template.HTML
+		line []string
+	)
+	for _, tok := range lex(src) {
+		// Tokens such as block comments may span several lines.
+		parts := strings.Split(tok.Text, "\n")
+		for i, p := range parts {
+			if i > 0 {
+				out = append(out, template.HTML(strings.Join(line, "")))
+				line = line[:0]
+			}
+			if p == "" {
+				continue
+			}
+			if tok.Class == "" {
+				line = append(line, html.EscapeString(p))
+			} else {
+				line = append(line, `<span class="tok-`+tok.Class+`">`+html.EscapeString(p)+`</span>`)
+			}
+		}
+	}
+	return append(out, template.HTML(strings.Join(line, "")))
+}
+
+// highlightLines sets the highlighted HTML of each of the given lines of
+// code from a file with extension ext, emphasising the lines marked HL.
+// Lines that belong to highlight steps are emphasised by the client instead.
+func highlightLines(lines []codeLine, ext string) {
+	src := make([]string, len(lines))
+	for i, l := range lines {
+		src[i] = l.L
+	}
+	hl := highlight(strings.Join(src, "\n"), ext)
+	for i := range lines {
+		if !lines[i].HL || lines[i].Steps != nil {
+			lines[i].H = hl[i]
+			continue
+		}
+		// Emphasise the line without its leading and trailing space. The
+		// space is plain text, so it is at the edges of the HTML too.
+		h := string(hl[i])
+		trimmed := strings.TrimLeftFunc(h, unicode.IsSpace)
+		lead := h[:len(h)-len(trimmed)]
+		lines[i].H = template.HTML(lead + "<b>" + strings.TrimRightFunc(trimmed, unicode.IsSpace) + "</b>")
+	}
+}
+
+// lexGo splits Go source code into tokens using go/scanner.
+func lexGo(src string) []Token {
+	var (
+		toks []Token
+		s    scanner.Scanner
+		last int // offset of the end of the last token
+	)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	// Snippets need not be valid Go, so errors are ignored.
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // automatically inserted
+		}
+		off := file.Offset(pos)
+		text := lit
+		if text == "" || tok == token.SEMICOLON {
+			text = tok.String()
+		}
+		if off < last || off+len(text) > len(src) {
+			continue
+		}
+		if off > last {
+			toks = append(toks, Token{Text: src[last:off]})
+		}
+		var class string
+		switch {
+		case tok.IsKeyword():
+			class = ClassKeyword
+		case tok == token.COMMENT:
+			class = ClassComment
+		case tok == token.STRING || tok == token.CHAR:
+			class = ClassString
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = ClassNumber
+		case tok == token.IDENT && goBuiltins[lit]:
+			class = ClassBuiltin
+		}
+		toks = append(toks, Token{Class: class, Text: src[off : off+len(text)]})
+		last = off + len(text)
+	}
+	if last < len(src) {
+		toks = append(toks, Token{Text: src[last:]})
+	}
+	return toks
+}
+
+// goBuiltins holds Go's predeclared identifiers.
+var goBuiltins = make(map[string]bool)
+
+func init() {
+	for _, name := range strings.Fields(`bool byte complex64 complex128 error float32 float64
+		int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr
+		true false iota nil
+		append cap close complex copy delete imag len make new panic print println real recover`) {
+		goBuiltins[name] = true
+	}
+}
+
+// simpleLexer is a lexer for languages with C- or shell-like syntax. It
+// recognises keywords, comments, quoted strings and numbers.
+type simpleLexer struct {
+	keywords     map[string]bool
+	lineComment  string    // starts a comment that runs to the end of the line
+	blockComment [2]string // delimits a comment that may span lines
+	quotes       string    // characters that delimit strings
+	caseless     bool      // whether keywords are case-insensitive
+}
+
+// with returns a copy of l that recognises the space-separated keywords.
+func (l *simpleLexer) with(keywords string) LexFunc {
+	c := *l
+	c.keywords = make(map[string]bool)
+	for _, k := range strings.Fields(keywords) {
+		c.keywords[k] = true
+	}
+	return c.lex
+}
+
+func (l *simpleLexer) lex(src string) []Token {
+	var toks []Token
+	plain := 0 // start of the pending plain text
+	emit := func(start, end int, class string) {
+		if start > plain {
+			toks = append(toks, Token{Text: src[plain:start]})
+		}
+		toks = append(toks, Token{Class: class, Text: src[start:end]})
+		plain = end
+	}
+	for i := 0; i < len(src); {
+		rest := src[i:]
+		switch c := src[i]; {
+		case l.lineComment != "" && strings.HasPrefix(rest, l.lineComment):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(i, i+end, ClassComment)
+			i += end
+		case l.blockComment[0] != "" && strings.HasPrefix(rest, l.blockComment[0]):
+			end := strings.Index(rest[len(l.blockComment[0]):], l.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(l.blockComment[0]) + len(l.blockComment[1])
+			}
+			emit(i, i+end, ClassComment)
+			i += end
+		case strings.IndexByte(l.quotes, c) >= 0:
+			end := 1
+			for end < len(rest) && rest[end] != c {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(rest) {
+				end++ // closing quote
+			} else {
+				end = len(rest)
+			}
+			emit(i, i+end, ClassString)
+			i += end
+		case isWordByte(c):
+			end := 1
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			word := rest[:end]
+			if l.caseless {
+				word = strings.ToLower(word)
+			}
+			if l.keywords[word] {
+				emit(i, i+end, ClassKeyword)
+			} else if c >= '0' && c <= '9' {
+				emit(i, i+end, ClassNumber)
+			}
+			i += end
+		default:
+			i++
+		}
+	}
+	if plain < len(src) {
+		toks = append(toks, Token{Text: src[plain:]})
+	}
+	return toks
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
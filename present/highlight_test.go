@@ -0,0 +1,76 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		ext, src string
+		want     []template.HTML
+	}{
+		{
+			".go",
+			"func f() int {\n\treturn 1 // one\n}",
+			[]template.HTML{
+				`<span class="tok-keyword">func</span> f() <span class="tok-builtin">int</span> {`,
+				"\t" + `<span class="tok-keyword">return</span> <span class="tok-number">1</span> <span class="tok-comment">// one</span>`,
+				`}`,
+			},
+		},
+		{
+			".go",
+			"s := `a\n<b>`",
+			[]template.HTML{
+				"s := <span class=\"tok-string\">`a</span>",
+				"<span class=\"tok-string\">&lt;b&gt;`</span>",
+			},
+		},
+		{
+			".py",
+			"def f(): # 'x'\n  return \"y\"",
+			[]template.HTML{
+				`<span class="tok-keyword">def</span> f(): <span class="tok-comment"># &#39;x&#39;</span>`,
+				`  <span class="tok-keyword">return</span> <span class="tok-string">&#34;y&#34;</span>`,
+			},
+		},
+		{
+			".txt",
+			"if a < b\n",
+			[]template.HTML{`if a &lt; b`, ``},
+		},
+	}
+	for _, tt := range tests {
+		got := highlight(tt.src, tt.ext)
+		if len(got) != len(tt.want) {
+			t.Errorf("highlight(%q, %q) = %q; want %q", tt.src, tt.ext, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("highlight(%q, %q) line %d = %q; want %q", tt.src, tt.ext, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestHighlightLines(t *testing.T) {
+	lines := []codeLine{
+		{L: "x := 1", N: 1},
+		{L: "    return x ", N: 2, HL: true},
+	}
+	highlightLines(lines, ".go")
+	want := `    <b><span class="tok-keyword">return</span> x</b>`
+	if got := string(lines[1].H); got != want {
+		t.Errorf("highlighted line = %q; want %q", got, want)
+	}
+	if got := string(lines[0].H); !strings.Contains(got, `<span class="tok-number">1</span>`) {
+		t.Errorf("line without HL = %q; want number span", got)
+	}
+}
@@ -21,10 +21,11 @@ func parseHTML(ctx *Context, fileName string, lineno int, text string) (Elem, er
 	if err != nil {
 		return nil, err
 	}
-	return HTML{template.HTML(b)}, nil
+	return HTML{Pos: linePos(fileName, lineno), HTML: template.HTML(b)}, nil
 }
 
 type HTML struct {
+	Pos
 	template.HTML
 }
 
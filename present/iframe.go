@@ -14,6 +14,7 @@ func init() {
 }
 
 type Iframe struct {
+	Pos
 	URL    string
 	Width  int
 	Height int
@@ -23,7 +24,7 @@ func (i Iframe) TemplateName() string { return "iframe" }
 
 func parseIframe(ctx *Context, fileName string, lineno int, text string) (Elem, error) {
 	args := strings.Fields(text)
-	i := Iframe{URL: args[1]}
+	i := Iframe{Pos: linePos(fileName, lineno), URL: args[1]}
 	a, err := parseArgs(fileName, lineno, args[2:])
 	if err != nil {
 		return nil, err
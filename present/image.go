@@ -14,6 +14,7 @@ func init() {
 }
 
 type Image struct {
+	Pos
 	URL    string
 	Width  int
 	Height int
@@ -23,7 +24,7 @@ func (i Image) TemplateName() string { return "image" }
 
 func parseImage(ctx *Context, fileName string, lineno int, text string) (Elem, error) {
 	args := strings.Fields(text)
-	img := Image{URL: args[1]}
+	img := Image{Pos: linePos(fileName, lineno), URL: args[1]}
 	a, err := parseArgs(fileName, lineno, args[2:])
 	if err != nil {
 		return nil, err
@@ -0,0 +1,134 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// isInclude reports whether text is an include directive.
+func isInclude(text string) bool {
+	return text == ".include" || strings.HasPrefix(text, ".include ")
+}
+
+// includeSections parses the sections of the file named by an include
+// directive. Its syntax:
+//   .include <filename> [range]
+// The range selects the top-level sections of the included file to keep,
+// and is either a single section number or a span such as 2-4, 2- or -4.
+// The headings of the included file are shifted to the level indicated by
+// number so that its sections are spliced in at the current nesting level.
+func includeSections(ctx *Context, name string, lines *Lines, text string, number []int, doc *Doc) ([]Section, error) {
+	args := strings.Fields(text)
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("%s:%d: syntax error for .include invocation", name, lines.line)
+	}
+	filename := filepath.Join(filepath.Dir(name), args[1])
+	for l := lines; l != nil; l = l.includer {
+		if filepath.Clean(l.name) == filename {
+			return nil, fmt.Errorf("%s:%d: include cycle: %s includes itself", name, lines.line, filename)
+		}
+	}
+	b, err := ctx.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", name, lines.line, err)
+	}
+	inc, err := readLines(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", name, lines.line, err)
+	}
+	inc.name = filename
+	inc.includer = lines
+
+	// Variables defined by the included file's header apply to it, but those
+	// of the including document take precedence.
+	vars := map[string]string{}
+	for _, comment := range inc.headerComments() {
+		setVar(vars, comment)
+	}
+	for k, v := range doc.Vars {
+		vars[k] = v
+	}
+	inc.vars = vars
+
+	// The included file may be a complete document; if so, skip its header
+	// and authors.
+	if text, ok := inc.nextNonEmpty(); ok && !isHeading.MatchString(text) && !isInclude(text) {
+		inc.back()
+		if err := parseHeader(&Doc{Vars: map[string]string{}}, inc); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", name, lines.line, filename, err)
+		}
+		if _, err := parseAuthors(inc); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", name, lines.line, filename, err)
+		}
+	} else {
+		inc.back()
+	}
+	inc.takeComment()
+
+	if depth := len(number); depth > 0 {
+		stars := strings.Repeat("*", depth)
+		for i := inc.line; i < len(inc.text); i++ {
+			if isHeading.MatchString(inc.text[i]) {
+				inc.text[i] = stars + inc.text[i]
+			}
+		}
+	}
+	sections, err := parseSections(ctx, filename, inc, number, doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", name, lines.line, err)
+	}
+
+	if len(args) == 3 {
+		lo, hi, err := parseSectionRange(args[2], len(sections))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, lines.line, err)
+		}
+		sections = sections[lo-1 : hi]
+	}
+	return sections, nil
+}
+
+// parseSectionRange parses a 1-indexed, inclusive range of sections such as
+// 3, 2-4, 2- or -4, where n is the number of sections available.
+func parseSectionRange(s string, n int) (lo, hi int, err error) {
+	bad := fmt.Errorf("bad section range %q", s)
+	lo, hi = 1, n
+	from, to := s, s
+	if i := strings.Index(s, "-"); i >= 0 {
+		from, to = s[:i], s[i+1:]
+	}
+	if from != "" {
+		if lo, err = strconv.Atoi(from); err != nil {
+			return 0, 0, bad
+		}
+	}
+	if to != "" {
+		if hi, err = strconv.Atoi(to); err != nil {
+			return 0, 0, bad
+		}
+	}
+	if lo < 1 || hi > n || lo > hi {
+		return 0, 0, fmt.Errorf("section range %q is out of range (file has %d sections)", s, n)
+	}
+	return lo, hi, nil
+}
+
+// renumber returns s with the section number at the given depth set to n,
+// and its subsections renumbered to match.
+func renumber(s Section, depth, n int) Section {
+	s.Number = append([]int{}, s.Number...)
+	s.Number[depth] = n
+	for i, e := range s.Elem {
+		if ss, ok := e.(Section); ok {
+			s.Elem[i] = renumber(ss, depth, n)
+		}
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"encoding/json"
+)
+
+// The JSON encoding of a document tags each element with its type, which is
+// the name of the template used to render it, in a "Type" field:
+//   {"Type": "list", "Bullet": ["one", "two"], ...}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s Section) MarshalJSON() ([]byte, error) {
+	elems, err := marshalElems(s.Elem)
+	if err != nil {
+		return nil, err
+	}
+	type section Section // Has no MarshalJSON method.
+	return json.Marshal(struct {
+		Type string
+		section
+		Elem []json.RawMessage
+	}{s.TemplateName(), section(s), elems})
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (a Author) MarshalJSON() ([]byte, error) {
+	elems, err := marshalElems(a.Elem)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Elem []json.RawMessage
+	}{elems})
+}
+
+func marshalElems(elems []Elem) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(elems))
+	for _, e := range elems {
+		b, err := marshalElem(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// marshalElem returns the JSON encoding of e, tagged with its type.
+func marshalElem(e Elem) (json.RawMessage, error) {
+	var v interface{} = e
+	switch e := e.(type) {
+	case Section:
+		// Section tags itself.
+		return json.Marshal(e)
+	case Build:
+		inner, err := marshalElem(e.Elem)
+		if err != nil {
+			return nil, err
+		}
+		v = struct {
+			Pos
+			Elem json.RawMessage
+		}{e.Position(), inner}
+	case Link:
+		// Encode the URL as a string rather than as its parts.
+		type link Link
+		var url string
+		if e.URL != nil {
+			url = e.URL.String()
+		}
+		v = struct {
+			link
+			URL string
+		}{link(e), url}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	t, err := json.Marshal(e.TemplateName())
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] != '{' {
+		return json.Marshal(struct {
+			Type  string
+			Value json.RawMessage
+		}{e.TemplateName(), b})
+	}
+	// Add the Type field at the start of the object.
+	tagged := append([]byte(`{"Type":`), t...)
+	if len(b) > 2 {
+		tagged = append(tagged, ',')
+	}
+	return append(tagged, b[1:]...), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	const src = `#+theme=white
+Title
+Tags: a, b
+
+Author
+http://example.com/
+
+* Slide
+
+#+build
+- one
+
+.link http://golang.org Go
+
+** Sub
+
+Text.
+`
+	b, err := json.Marshal(parseString(t, src))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var doc struct {
+		Title    string
+		Tags     []string
+		Theme    string
+		Authors  []struct{ Elem []map[string]interface{} }
+		Sections []map[string]interface{}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if doc.Title != "Title" || doc.Theme != "white" || !reflect.DeepEqual(doc.Tags, []string{"a", "b"}) {
+		t.Errorf("header: got %+v", doc)
+	}
+	if got := doc.Authors[0].Elem[1]; got["Type"] != "link" || got["URL"] != "http://example.com/" {
+		t.Errorf("author link: got %v", got)
+	}
+
+	// Collect the types of the elements in the first section.
+	var types []interface{}
+	var walk func(elems []interface{})
+	walk = func(elems []interface{}) {
+		for _, e := range elems {
+			e := e.(map[string]interface{})
+			types = append(types, e["Type"])
+			if inner, ok := e["Elem"].(map[string]interface{}); ok {
+				walk([]interface{}{inner})
+			}
+			if inner, ok := e["Elem"].([]interface{}); ok {
+				walk(inner)
+			}
+		}
+	}
+	walk(doc.Sections[0]["Elem"].([]interface{}))
+	want := []interface{}{"build", "list", "link", "section", "text"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("element types: got %v, want %v", types, want)
+	}
+	if got := doc.Sections[0]["Line"]; got != 8.0 {
+		t.Errorf("section line: got %v, want 8", got)
+	}
+}
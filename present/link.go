@@ -16,6 +16,7 @@ func init() {
 }
 
 type Link struct {
+	Pos
 	URL   *url.URL
 	Label string
 }
@@ -38,7 +39,7 @@ func parseLink(ctx *Context, fileName string, lineno int, text string) (Elem, er
 		}
 		label = strings.Replace(url.String(), scheme, "", 1)
 	}
-	return Link{url, label}, nil
+	return Link{Pos: linePos(fileName, lineno), URL: url, Label: label}, nil
 }
 
 func renderLink(href, text string) string {
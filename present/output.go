@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register("output", parseOutput)
+}
+
+// Output is the output of a command run when the document is parsed.
+type Output struct {
+	Pos
+	Command string // the command, as written
+	Text    string // what it wrote to its standard output and error
+}
+
+func (o Output) TemplateName() string { return "output" }
+
+// notRun is shown in place of the output of commands that are not run
+// because the playground is off.
+const notRun = "[not run: the playground is turned off]"
+
+// parseOutput parses an output present directive. Its syntax:
+//   .output <command> [args...]
+//   .output -file <script>
+// The command is run, without a shell, in the directory of the present
+// file; a script is run with sh. Nothing is run if the playground is off.
+func parseOutput(ctx *Context, sourceFile string, sourceLine int, cmd string) (Elem, error) {
+	args := strings.Fields(cmd)[1:]
+	command := strings.Join(args, " ")
+	script := len(args) > 0 && args[0] == "-file"
+	if script {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s:%d: syntax error for .output invocation", sourceFile, sourceLine)
+		}
+		args = []string{"sh", args[1]}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s:%d: syntax error for .output invocation", sourceFile, sourceLine)
+	}
+	if ctx.Output == nil {
+		return nil, fmt.Errorf("%s:%d: cannot run %s: commands are not supported", sourceFile, sourceLine, command)
+	}
+	// Commands run with the rights of whoever presents the document,
+	// like its snippets, so they run only where the snippets may.
+	if !ctx.Play.Enabled {
+		return Output{
+			Pos:     linePos(sourceFile, sourceLine),
+			Command: command,
+			Text:    notRun,
+		}, nil
+	}
+	out, err := ctx.Output(filepath.Dir(sourceFile), args, script)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", sourceFile, sourceLine, err)
+	}
+	return Output{
+		Pos:     linePos(sourceFile, sourceLine),
+		Command: command,
+		Text:    strings.TrimRight(string(out), "\n"),
+	}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	var gotDir string
+	var gotArgs []string
+	var gotScript bool
+	ctx := &Context{
+		Output: func(dir string, args []string, script bool) ([]byte, error) {
+			gotDir, gotArgs, gotScript = dir, args, script
+			return []byte("ok\n\n"), nil
+		},
+		Play: PlayOptions{Enabled: true},
+	}
+	tests := []struct {
+		cmd    string
+		args   []string
+		script bool
+	}{
+		{".output go vet ./...", []string{"go", "vet", "./..."}, false},
+		{".output sh bench.sh", []string{"sh", "bench.sh"}, false},
+		{".output -file bench.sh", []string{"sh", "bench.sh"}, true},
+	}
+	for _, tt := range tests {
+		e, err := parseOutput(ctx, "talks/test.slide", 1, tt.cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.cmd, err)
+			continue
+		}
+		if gotDir != "talks" || !reflect.DeepEqual(gotArgs, tt.args) || gotScript != tt.script {
+			t.Errorf("%s: ran %q (script %v) in %s; want %q (script %v) in talks", tt.cmd, gotArgs, gotScript, gotDir, tt.args, tt.script)
+		}
+		o := e.(Output)
+		if want := strings.TrimPrefix(tt.cmd, ".output "); o.Command != want || o.Text != "ok" {
+			t.Errorf("%s: got %+v", tt.cmd, o)
+		}
+	}
+
+	for _, cmd := range []string{".output", ".output -file", ".output -file a.sh b.sh"} {
+		if _, err := parseOutput(ctx, "test.slide", 1, cmd); err == nil {
+			t.Errorf("%s: no error", cmd)
+		}
+	}
+	if _, err := parseOutput(&Context{}, "test.slide", 1, ".output go vet"); err == nil {
+		t.Errorf("without Context.Output: no error")
+	}
+
+	// Nothing runs when the playground is off.
+	gotArgs = nil
+	ctx.Play.Enabled = false
+	e, err := parseOutput(ctx, "test.slide", 1, ".output go vet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotArgs != nil || e.(Output).Text != notRun {
+		t.Errorf("with the playground off: ran %q, got %+v", gotArgs, e)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/url"
+	"os"
 	"regexp"
 	// "strconv"
 	"strings"
@@ -38,7 +39,7 @@ func (d *Doc) Render(w io.Writer, t *template.Template) error {
 		*Doc
 		Template    *template.Template
 		PlayEnabled bool
-	}{d, t, PlayEnabled}
+	}{d, t, d.Play.Enabled}
 	return t.ExecuteTemplate(w, "root", data)
 }
 
@@ -48,10 +49,32 @@ func (s *Section) Render(w io.Writer, t *template.Template) error {
 		*Section
 		Template    *template.Template
 		PlayEnabled bool
-	}{s, t, PlayEnabled}
+	}{s, t, hasPlay(s.Elem)}
 	return t.ExecuteTemplate(w, "section", data)
 }
 
+// hasPlay reports whether any of elems, or the elements they contain, is
+// runnable code.
+func hasPlay(elems []Elem) bool {
+	for _, e := range elems {
+		switch e := e.(type) {
+		case Code:
+			if e.Play {
+				return true
+			}
+		case Build:
+			if hasPlay([]Elem{e.Elem}) {
+				return true
+			}
+		case Section:
+			if hasPlay(e.Elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type ParseFunc func(ctx *Context, fileName string, lineNumber int, inputLine string) (Elem, error)
 
 // Register binds the named action, which does not begin with a period, to the
@@ -75,8 +98,12 @@ type Doc struct {
 	ArticleStylesheets []string
 	SlideStylesheets   []string
 	Theme              string
+	Play               PlayOptions // the playground options in effect
 	HideLastSlide      string
 	ClosingMessage     string
+	CodeTransitions    bool
+	Vars               map[string]string
+	Comments           []string // header comments; kept in Source mode only
 }
 
 // Author represents the person who wrote and/or is presenting the document.
@@ -100,9 +127,13 @@ func (p *Author) TextElem() (elems []Elem) {
 // Section represents a section of a document (such as a presentation slide)
 // comprising a title and a list of elements.
 type Section struct {
-	Number []int
-	Title  string
-	Elem   []Elem
+	Pos
+	Number   []int
+	Title    string
+	Elem     []Elem
+	Comments []string // comments preceding the heading; kept in Source mode only
+
+	include bool // stands in for an unevaluated include in Source mode
 }
 
 func (s Section) Sections() (sections []Section) {
@@ -138,15 +169,48 @@ type Elem interface {
 	TemplateName() string
 }
 
+// Pos describes the span of source lines an element was parsed from.
+type Pos struct {
+	File    string // name of the source file
+	Line    int    // first line, 1-indexed
+	EndLine int    // last line, inclusive
+}
+
+// Position returns p. It is promoted to the element types that embed a Pos.
+func (p Pos) Position() Pos { return p }
+
+// linePos returns the Pos of the single line of a file, such as that of an
+// invocation.
+func linePos(file string, line int) Pos {
+	return Pos{File: file, Line: line, EndLine: line}
+}
+
+// Position returns the source position of e, or the zero Pos if e does not
+// record one.
+func Position(e Elem) Pos {
+	if p, ok := e.(interface {
+		Position() Pos
+	}); ok {
+		return p.Position()
+	}
+	return Pos{}
+}
+
 // renderElem implements the elem template function, used to render
 // sub-templates.
 func renderElem(t *template.Template, e Elem) (template.HTML, error) {
 	var data interface{} = e
-	if s, ok := e.(Section); ok {
+	switch e := e.(type) {
+	case Section:
 		data = struct {
 			Section
 			Template *template.Template
-		}{s, t}
+		}{e, t}
+	case Build:
+		data = struct {
+			Build
+			Template *template.Template
+		}{e, t}
 	}
 	return execTemplate(t, e.TemplateName(), data)
 }
@@ -168,6 +232,7 @@ func execTemplate(t *template.Template, name string, data interface{}) (template
 
 // Text represents an optionally preformatted paragraph.
 type Text struct {
+	Pos
 	Lines []string
 	Pre   bool
 }
@@ -176,15 +241,52 @@ func (t Text) TemplateName() string { return "text" }
 
 // List represents a bulleted list.
 type List struct {
+	Pos
 	Bullet []string
 }
 
 func (l List) TemplateName() string { return "list" }
 
+// Comment represents a run of comment lines. Comments are only kept when
+// parsing in Source mode.
+type Comment struct {
+	Pos
+	Lines []string
+}
+
+func (c Comment) TemplateName() string { return "comment" }
+
+// Invocation represents an invocation such as .code or .image kept as
+// written rather than evaluated, as is done when parsing in Source mode.
+type Invocation struct {
+	Pos
+	Name string   // name of the function, including the period
+	Args []string // the space-separated arguments
+}
+
+func (i Invocation) TemplateName() string { return "invocation" }
+
+// Build wraps an element that is revealed incrementally when presented as
+// a slide. List items are revealed one at a time; any other element is
+// revealed as a whole.
+type Build struct {
+	Elem
+}
+
+func (b Build) TemplateName() string { return "build" }
+
+// Position returns the source position of the built element.
+func (b Build) Position() Pos { return Position(b.Elem) }
+
 // Lines is a helper for parsing line-based input.
 type Lines struct {
-	line int // 0 indexed, so has 1-indexed number of last line returned
-	text []string
+	line     int // 0 indexed, so has 1-indexed number of last line returned
+	text     []string
+	comments []int             // indexes of comments skipped since the last takeComments
+	mode     ParseMode         // mode the lines are being parsed in
+	name     string            // name of the file the lines were read from
+	includer *Lines            // lines of the including file, if any
+	vars     map[string]string // variables expanded in non-comment lines
 }
 
 func readLines(r io.Reader) (*Lines, error) {
@@ -196,7 +298,7 @@ func readLines(r io.Reader) (*Lines, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	return &Lines{0, lines}, nil
+	return &Lines{line: 0, text: lines}, nil
 }
 
 func (l *Lines) next() (text string, ok bool) {
@@ -209,9 +311,15 @@ func (l *Lines) next() (text string, ok bool) {
 		text = l.text[current]
 		// Lines starting with # are comments.
 		if len(text) == 0 || text[0] != '#' {
+			// Preformatted text, which is indented, is shown as written.
+			if r, _ := utf8.DecodeRuneInString(text); !unicode.IsSpace(r) {
+				text = expandVars(text, l.vars)
+			}
 			ok = true
 			break
 		}
+		// Comments are kept aside for the element that follows them.
+		l.comments = append(l.comments, current)
 	}
 	return
 }
@@ -220,6 +328,37 @@ func (l *Lines) back() {
 	l.line--
 }
 
+// pos returns the span from line start to the last non-blank, non-comment
+// line consumed so far.
+func (l *Lines) pos(start int) Pos {
+	end := l.line
+	if end > len(l.text) {
+		end = len(l.text)
+	}
+	for end > start {
+		text := l.text[end-1]
+		if strings.TrimSpace(text) != "" && text[0] != '#' {
+			break
+		}
+		end--
+	}
+	return Pos{File: l.name, Line: start, EndLine: end}
+}
+
+// takeComment returns the comment lines skipped since the last call and
+// clears them. The Comment has no lines if there were none.
+func (l *Lines) takeComment() (c Comment) {
+	if len(l.comments) == 0 {
+		return
+	}
+	c.Pos = Pos{File: l.name, Line: l.comments[0] + 1, EndLine: l.comments[len(l.comments)-1] + 1}
+	for _, i := range l.comments {
+		c.Lines = append(c.Lines, l.text[i])
+	}
+	l.comments = nil
+	return
+}
+
 func (l *Lines) nextNonEmpty() (text string, ok bool) {
 	for {
 		text, ok = l.next()
@@ -256,6 +395,39 @@ func (l *Lines) headerComments() (comments []string) {
 type Context struct {
 	// ReadFile reads the file named by filename and returns the contents.
 	ReadFile func(filename string) ([]byte, error)
+
+	// ReadDir, if set, reads the directory named by dirname and returns
+	// its entries, as needed to find the files of a package for .godoc.
+	ReadDir func(dirname string) ([]os.FileInfo, error)
+
+	// ReadFileAt, if set, reads the file named by filename as it was at
+	// the revision rev of the version control repository that holds it.
+	ReadFileAt func(filename, rev string) ([]byte, error)
+
+	// Output, if set, runs the command args of an .output element in the
+	// directory dir and returns what it writes to its standard output and
+	// error. A command that fails is not an error if it ran: its output
+	// is shown, followed by why it failed. If script is set, the command
+	// is sh running a script given with -file, which may be allowed
+	// apart from running sh itself.
+	Output func(dir string, args []string, script bool) ([]byte, error)
+
+	// Asm, if set, compiles the Go file named filename for an .asm
+	// element, for the architecture goarch or, if it is empty, that of
+	// the host, and returns the assembly listing printed by the
+	// compiler's -S flag.
+	Asm func(filename, goarch string) ([]byte, error)
+
+	// Play controls which .play snippets may be run. A document may
+	// restrict it further with the header comment #+play=false, which
+	// turns the playground off, or #+play=readonly, and set how its
+	// snippets are built with #+playModule=, #+playGo=, #+playGoFlags=
+	// and #+playTags=.
+	Play PlayOptions
+
+	// Vars holds the default values of the variables referenced in the
+	// document. Variables defined in the document header take precedence.
+	Vars map[string]string
 }
 
 // ParseMode represents flags for the Parse function.
@@ -264,6 +436,11 @@ type ParseMode int
 const (
 	// If set, parse only the title and subtitle.
 	TitlesOnly ParseMode = 1
+
+	// If set, keep the document as written for tools that rewrite it:
+	// comments are kept, invocations are kept as Invocation elements
+	// rather than evaluated, and variables are not expanded.
+	Source ParseMode = 2
 )
 
 // Parse parses a document from r.
@@ -273,10 +450,22 @@ func (ctx *Context) Parse(r io.Reader, name string, mode ParseMode) (*Doc, error
 	if err != nil {
 		return nil, err
 	}
+	lines.name = name
+	lines.mode = mode
 	doc.ArticleStylesheets = []string{}
 	doc.SlideStylesheets = []string{}
 	doc.HideLastSlide = ""
 	doc.ClosingMessage = ""
+	doc.Play = ctx.Play
+	doc.Vars = map[string]string{}
+	for k, v := range ctx.Vars {
+		doc.Vars[k] = v
+	}
+	// parseHeader adds the variables defined by the header to the same map,
+	// so they are in effect by the time the title is read.
+	if mode&Source == 0 {
+		lines.vars = doc.Vars
+	}
 	err = parseHeader(doc, lines)
 	if err != nil {
 		return nil, err
@@ -288,17 +477,24 @@ func (ctx *Context) Parse(r io.Reader, name string, mode ParseMode) (*Doc, error
 	if doc.Authors, err = parseAuthors(lines); err != nil {
 		return nil, err
 	}
-	// Sections
-	if doc.Sections, err = parseSections(ctx, name, lines, []int{}, doc); err != nil {
+	// Sections, parsed with the playground options of the document.
+	docCtx := *ctx
+	docCtx.Play = doc.Play
+	if doc.Sections, err = parseSections(&docCtx, name, lines, []int{}, doc); err != nil {
 		return nil, err
 	}
+	if doc.CodeTransitions && mode&Source == 0 {
+		if err := linkCodeTransitions(doc.Sections); err != nil {
+			return nil, err
+		}
+	}
 	return doc, nil
 }
 
 // Parse parses a document from r. Parse reads assets used by the presentation
-// from the file system using ioutil.ReadFile.
+// from the file system using ioutil.ReadFile and ioutil.ReadDir.
 func Parse(r io.Reader, name string, mode ParseMode) (*Doc, error) {
-	ctx := Context{ReadFile: ioutil.ReadFile}
+	ctx := Context{ReadFile: ioutil.ReadFile, ReadDir: ioutil.ReadDir}
 	return ctx.Parse(r, name, mode)
 }
 
@@ -315,7 +511,7 @@ func lesserHeading(text, prefix string) bool {
 // number (a nil number indicates the top level).
 func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *Doc) ([]Section, error) {
 	var sections []Section
-	for i := 1; ; i++ {
+	for {
 		// Next non-empty line is title.
 		text, ok := lines.nextNonEmpty()
 		for ok && text == "" {
@@ -324,18 +520,58 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 		if !ok {
 			break
 		}
+		if isInclude(text) && lines.mode&Source != 0 {
+			// Keep the include in a section of its own.
+			sections = append(sections, Section{
+				Pos:     lines.pos(lines.line),
+				Number:  append(append([]int{}, number...), len(sections)+1),
+				Elem:    []Elem{Invocation{Pos: lines.pos(lines.line), Name: ".include", Args: strings.Fields(text)[1:]}},
+				include: true,
+			})
+			continue
+		}
+		if isInclude(text) {
+			included, err := includeSections(ctx, name, lines, text, number, doc)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range included {
+				sections = append(sections, renumber(s, len(number), len(sections)+1))
+			}
+			continue
+		}
 		prefix := strings.Repeat("*", len(number)+1)
 		if !strings.HasPrefix(text, prefix+" ") {
 			lines.back()
 			break
 		}
 		section := Section{
-			Number: append(append([]int{}, number...), i),
+			Number: append(append([]int{}, number...), len(sections)+1),
 			Title:  text[len(prefix)+1:],
 		}
+		start := lines.line
+		// Comments preceding a heading do not apply to any element.
+		if c := lines.takeComment(); lines.mode&Source != 0 {
+			section.Comments = c.Lines
+		}
 		text, ok = lines.nextNonEmpty()
-		for ok && !lesserHeading(text, prefix) {
+		for ok && !lesserHeading(text, prefix) && !(isInclude(text) && lines.mode&Source == 0) {
 			var e Elem
+			elemStart := lines.line
+			// A #+build comment marks the element that follows for
+			// incremental display.
+			build := false
+			comment := lines.takeComment()
+			for i := 0; i < len(comment.Lines); i++ {
+				if strings.TrimSpace(comment.Lines[i]) == "#+build" {
+					build = true
+					comment.Lines = append(comment.Lines[:i], comment.Lines[i+1:]...)
+					i--
+				}
+			}
+			if len(comment.Lines) > 0 && lines.mode&Source != 0 {
+				section.Elem = append(section.Elem, comment)
+			}
 			r, _ := utf8.DecodeRuneInString(text)
 			switch {
 			case unicode.IsSpace(r):
@@ -356,9 +592,11 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 				}
 				lines.back()
 				pre := strings.Join(s, "\n")
-				pre = strings.Replace(pre, "\t", "    ", -1) // browsers treat tabs badly
+				if lines.mode&Source == 0 {
+					pre = strings.Replace(pre, "\t", "    ", -1) // browsers treat tabs badly
+				}
 				pre = strings.TrimRightFunc(pre, unicode.IsSpace)
-				e = Text{Lines: []string{pre}, Pre: true}
+				e = Text{Pos: lines.pos(elemStart), Lines: []string{pre}, Pre: true}
 			case strings.HasPrefix(text, "- "):
 				var b []string
 				for ok && strings.HasPrefix(text, "- ") {
@@ -366,7 +604,7 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 					text, ok = lines.next()
 				}
 				lines.back()
-				e = List{Bullet: b}
+				e = List{Pos: lines.pos(elemStart), Bullet: b}
 			case strings.HasPrefix(text, prefix+"* "):
 				lines.back()
 				subsecs, err := parseSections(ctx, name, lines, section.Number, doc)
@@ -378,6 +616,10 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 				}
 			case strings.HasPrefix(text, "."):
 				args := strings.Fields(text)
+				if lines.mode&Source != 0 {
+					e = Invocation{Pos: lines.pos(elemStart), Name: args[0], Args: args[1:]}
+					break
+				}
 				parser := parsers[args[0]]
 				if parser == nil {
 					return nil, fmt.Errorf("%s:%d: unknown command %q\n", name, lines.line, text)
@@ -391,6 +633,7 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 				var l []string
 				for ok && strings.TrimSpace(text) != "" {
 					if text[0] == '.' { // Command breaks text block.
+						lines.back()
 						break
 					}
 					if strings.HasPrefix(text, `\.`) { // Backslash escapes initial period.
@@ -400,17 +643,27 @@ func parseSections(ctx *Context, name string, lines *Lines, number []int, doc *D
 					text, ok = lines.next()
 				}
 				if len(l) > 0 {
-					e = Text{Lines: l}
+					e = Text{Pos: lines.pos(elemStart), Lines: l}
 				}
 			}
 			if e != nil {
+				if build {
+					e = Build{e}
+				}
 				section.Elem = append(section.Elem, e)
 			}
 			text, ok = lines.nextNonEmpty()
 		}
-		if isHeading.MatchString(text) {
+		if isHeading.MatchString(text) || isInclude(text) {
 			lines.back()
 		}
+		// Comments at the end of the file belong to the last section.
+		if !ok && lines.mode&Source != 0 {
+			if c := lines.takeComment(); len(c.Lines) > 0 {
+				section.Elem = append(section.Elem, c)
+			}
+		}
+		section.Pos = lines.pos(start)
 		sections = append(sections, section)
 	}
 	return sections, nil
@@ -425,6 +678,7 @@ func parseHeader(doc *Doc, lines *Lines) error {
 		slideStyleStr := "#+slideStylesheet="
 		hideStr := "#+hideLastSlide="
 		closingMsgStr := "#+closingMessage="
+		playStr := "#+play="
 		for _, comment := range comments {
 			if strings.Index(comment, themeStr) == 0 {
 				doc.Theme = comment[len(themeStr):]
@@ -441,6 +695,32 @@ func parseHeader(doc *Doc, lines *Lines) error {
 			if strings.Index(comment, closingMsgStr) == 0 {
 				doc.ClosingMessage = comment[len(closingMsgStr):]
 			}
+			if strings.Index(comment, playStr) == 0 {
+				switch comment[len(playStr):] {
+				case "false":
+					doc.Play.Enabled = false
+				case "readonly":
+					doc.Play.ReadOnly = true
+				}
+			}
+			if v, ok := playSetting(comment, "Module"); ok {
+				doc.Play.Module = v
+			}
+			if v, ok := playSetting(comment, "Go"); ok {
+				doc.Play.Go = v
+			}
+			if v, ok := playSetting(comment, "GoFlags"); ok {
+				doc.Play.GoFlags = v
+			}
+			if v, ok := playSetting(comment, "Tags"); ok {
+				doc.Play.Tags = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+			}
+			if strings.Index(comment, transitionsPrefix) == 0 {
+				doc.CodeTransitions = comment[len(transitionsPrefix):] == "true"
+			}
+			if strings.Index(comment, varPrefix) == 0 {
+				setVar(doc.Vars, comment)
+			}
 		}
 	}
 	var ok bool
@@ -449,6 +729,9 @@ func parseHeader(doc *Doc, lines *Lines) error {
 	if !ok {
 		return errors.New("unexpected EOF; expected title")
 	}
+	if c := lines.takeComment(); lines.mode&Source != 0 {
+		doc.Comments = c.Lines
+	}
 	for {
 		text, ok := lines.next()
 		if !ok {
@@ -475,6 +758,18 @@ func parseHeader(doc *Doc, lines *Lines) error {
 	return nil
 }
 
+// playSetting returns the value of the header comment that sets the named
+// setting of the playground, such as
+//   #+playModule=../hello
+// and whether comment is such a comment.
+func playSetting(comment, name string) (string, bool) {
+	prefix := "#+play" + name + "="
+	if !strings.HasPrefix(comment, prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(comment[len(prefix):]), true
+}
+
 func parseAuthors(lines *Lines) (authors []Author, err error) {
 	// This grammar demarcates authors with blanks.
 
@@ -491,8 +786,8 @@ func parseAuthors(lines *Lines) (authors []Author, err error) {
 			return nil, errors.New("unexpected EOF")
 		}
 
-		// If we find a section heading, we're done.
-		if strings.HasPrefix(text, "* ") {
+		// If we find a section heading or an include, we're done.
+		if strings.HasPrefix(text, "* ") || isInclude(text) {
 			lines.back()
 			break
 		}
@@ -506,6 +801,7 @@ func parseAuthors(lines *Lines) (authors []Author, err error) {
 		if a == nil {
 			a = new(Author)
 		}
+		pos := Pos{File: lines.name, Line: lines.line, EndLine: lines.line}
 
 		// Parse the line. Those that
 		// - begin with @ are twitter names,
@@ -522,11 +818,12 @@ func parseAuthors(lines *Lines) (authors []Author, err error) {
 			el = parseURL("mailto:" + text)
 		}
 		if l, ok := el.(Link); ok {
+			l.Pos = pos
 			l.Label = text
 			el = l
 		}
 		if el == nil {
-			el = Text{Lines: []string{text}}
+			el = Text{Pos: pos, Lines: []string{text}}
 		}
 		a.Elem = append(a.Elem, el)
 	}
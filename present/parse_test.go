@@ -0,0 +1,277 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseString(t *testing.T, src string) *Doc {
+	doc, err := Parse(strings.NewReader(src), "test.slide", 0)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return doc
+}
+
+// withoutPos returns a copy of elems with their source positions cleared, so
+// that tests can compare element contents alone.
+func withoutPos(elems []Elem) []Elem {
+	var out []Elem
+	for _, e := range elems {
+		v := reflect.New(reflect.TypeOf(e)).Elem()
+		v.Set(reflect.ValueOf(e))
+		if f := v.FieldByName("Pos"); f.IsValid() {
+			f.Set(reflect.Zero(f.Type()))
+		}
+		if b, ok := e.(Build); ok {
+			v.Set(reflect.ValueOf(Build{withoutPos([]Elem{b.Elem})[0]}))
+		}
+		out = append(out, v.Interface().(Elem))
+	}
+	return out
+}
+
+func TestBuild(t *testing.T) {
+	const src = `#+theme=white
+Title
+
+Author
+
+* Slide
+
+Intro text.
+
+#+build
+- one
+- two
+
+#+build
+.caption built caption
+
+- plain
+`
+	doc := parseString(t, src)
+	if len(doc.Sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(doc.Sections))
+	}
+	want := []Elem{
+		Text{Lines: []string{"Intro text."}},
+		Build{List{Bullet: []string{"one", "two"}}},
+		Build{Caption{Text: "built caption"}},
+		List{Bullet: []string{"plain"}},
+	}
+	if got := withoutPos(doc.Sections[0].Elem); !reflect.DeepEqual(got, want) {
+		t.Errorf("elements:\ngot\t%#v\nwant\t%#v", got, want)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	files := map[string]string{
+		"about.slide": `About
+Subtitle
+
+Author
+
+* About one
+
+One.
+
+* About two
+
+** Details
+
+Two.
+
+* About three
+`,
+		"part.slide": `* Part
+
+.include about.slide 2
+`,
+		"loop.slide": `* Loop
+
+.include loop.slide
+`,
+		"bad.slide": `* Bad
+
+.nosuchcommand
+`,
+	}
+	ctx := &Context{ReadFile: func(name string) ([]byte, error) {
+		if s, ok := files[name]; ok {
+			return []byte(s), nil
+		}
+		return nil, fmt.Errorf("%s: not found", name)
+	}}
+	parse := func(src string) (*Doc, error) {
+		return ctx.Parse(strings.NewReader(src), "talk.slide", 0)
+	}
+
+	doc, err := parse(`Title
+
+Author
+
+* First
+
+.include about.slide 2-
+
+* Last
+
+** Sub
+
+.include part.slide
+`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var got []string
+	var walk func([]Section)
+	walk = func(sections []Section) {
+		for _, s := range sections {
+			got = append(got, s.FormattedNumber()+" "+s.Title)
+			walk(s.Sections())
+		}
+	}
+	walk(doc.Sections)
+	want := []string{
+		"1. First",
+		"2. About two",
+		"2.1. Details",
+		"3. About three",
+		"4. Last",
+		"4.1. Sub",
+		"4.2. Part",
+		"4.3. About two",
+		"4.3.1. Details",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections:\ngot\t%q\nwant\t%q", got, want)
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{".include loop.slide\n", "include cycle"},
+		{".include bad.slide\n", "talk.slide:5: bad.slide:3: unknown command"},
+		{".include about.slide 4\n", "out of range"},
+		{".include missing.slide\n", "missing.slide: not found"},
+	} {
+		_, err := parse("Title\n\nAuthor\n\n" + test.src)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("include %q: got error %v, want %q", test.src, err, test.err)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	ctx := &Context{
+		ReadFile: func(name string) ([]byte, error) {
+			return []byte("#+var where=Elsewhere\n\n* About {{event}} in {{where}}\n"), nil
+		},
+		Vars: map[string]string{"event": "Directory Event", "repo": "github.com/x/y"},
+	}
+	const src = `#+var event=GopherCon
+#+var version = 1.5
+
+{{event}} talk
+Version {{version}}
+
+Author
+
+* Welcome to {{event}}
+
+See {{repo}} for {{ version }}, not {{undefined}}.
+
+- {{event}}
+
+.caption {{event}}
+
+  {{event}} is shown as written in preformatted text.
+
+.include about.slide
+`
+	doc, err := ctx.Parse(strings.NewReader(src), "talk.slide", 0)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if doc.Title != "GopherCon talk" || doc.Subtitle != "Version 1.5" {
+		t.Errorf("header: got %q, %q", doc.Title, doc.Subtitle)
+	}
+	if got, want := doc.Sections[0].Title, "Welcome to GopherCon"; got != want {
+		t.Errorf("title: got %q, want %q", got, want)
+	}
+	want := []Elem{
+		Text{Lines: []string{"See github.com/x/y for 1.5, not {{undefined}}."}},
+		List{Bullet: []string{"GopherCon"}},
+		Caption{Text: "GopherCon"},
+		Text{Lines: []string{"{{event}} is shown as written in preformatted text."}, Pre: true},
+	}
+	if got := withoutPos(doc.Sections[0].Elem); !reflect.DeepEqual(got, want) {
+		t.Errorf("elements:\ngot\t%#v\nwant\t%#v", got, want)
+	}
+	if got, want := doc.Sections[1].Title, "About GopherCon in Elsewhere"; got != want {
+		t.Errorf("included title: got %q, want %q", got, want)
+	}
+}
+
+// A command right after a paragraph ends it and is parsed, rather than
+// being consumed by the paragraph and lost.
+func TestCommandEndsText(t *testing.T) {
+	doc := parseString(t, "Title\n\nAuthor\n\n* Slide\n\nSome text\n.caption Gopher\n")
+	want := []Elem{
+		Text{Lines: []string{"Some text"}},
+		Caption{Text: "Gopher"},
+	}
+	if got := withoutPos(doc.Sections[0].Elem); !reflect.DeepEqual(got, want) {
+		t.Errorf("elements:\ngot\t%#v\nwant\t%#v", got, want)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	const src = `Title
+
+Author
+@twitter
+
+* One
+
+Some text
+spanning lines.
+.caption right after
+
+#+build
+- a
+- b
+
+  pre
+  # not a comment
+
+* Two
+`
+	doc := parseString(t, src)
+	want := []Pos{
+		{"test.slide", 6, 17},
+		{"test.slide", 8, 9},
+		{"test.slide", 10, 10},
+		{"test.slide", 13, 14},
+		{"test.slide", 16, 17},
+		{"test.slide", 19, 19},
+	}
+	got := []Pos{doc.Sections[0].Pos}
+	for _, e := range doc.Sections[0].Elem {
+		got = append(got, Position(e))
+	}
+	got = append(got, doc.Sections[1].Pos)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("positions:\ngot\t%v\nwant\t%v", got, want)
+	}
+	if got, want := Position(doc.Authors[0].Elem[1]), (Pos{"test.slide", 4, 4}); got != want {
+		t.Errorf("author position: got %v, want %v", got, want)
+	}
+}
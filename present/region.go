@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regionPrefix introduces a region address such as region=setup.
+const regionPrefix = "region="
+
+var regionNameRE = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// isRegionAddr reports whether addr names a region of a file.
+func isRegionAddr(addr string) bool {
+	return strings.HasPrefix(addr, regionPrefix)
+}
+
+// regionToByteRange returns the byte range of the lines between the markers
+// of the region named by addr, which are lines ending in
+//   START name OMIT
+// and
+//   END name OMIT
+// Regions may nest; the markers of inner regions end in OMIT, so they are
+// dropped from the displayed code like any other OMIT line.
+func regionToByteRange(addr string, data []byte) (lo, hi int, err error) {
+	name := strings.TrimPrefix(addr, regionPrefix)
+	if !regionNameRE.MatchString(name) {
+		return 0, 0, fmt.Errorf("bad region name %q", name)
+	}
+	start := regexp.MustCompile(`\bSTART ` + regexp.QuoteMeta(name) + ` OMIT$`)
+	end := regexp.MustCompile(`\bEND ` + regexp.QuoteMeta(name) + ` OMIT$`)
+
+	lo = -1
+	depth := 0
+	for off := 0; off < len(data); {
+		line := data[off:]
+		next := len(data)
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = off + i + 1
+		}
+		line = bytes.TrimRight(line, " \t\r")
+		switch {
+		case start.Match(line):
+			if depth == 0 && lo < 0 {
+				lo = next
+			}
+			depth++
+		case end.Match(line):
+			if depth == 0 {
+				return 0, 0, fmt.Errorf("region %s ends before it starts", name)
+			}
+			if depth--; depth == 0 {
+				return lo, off, nil
+			}
+		}
+		off = next
+	}
+	if lo < 0 {
+		return 0, 0, fmt.Errorf("no region %s", name)
+	}
+	return 0, 0, fmt.Errorf("region %s has no END marker", name)
+}
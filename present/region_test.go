@@ -0,0 +1,68 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import "testing"
+
+const regionSrc = `package main
+
+func main() {
+	// START all OMIT
+	// START setup OMIT
+	x := 1
+	// END setup OMIT
+	// START setup2 OMIT
+	y := 2
+	// END setup2 OMIT
+	println(x + y)
+	// END all OMIT
+}
+
+# START shell OMIT
+echo hi
+# END shell OMIT
+`
+
+func TestRegionToByteRange(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"region=setup", "\tx := 1\n"},
+		{"region=setup2", "\ty := 2\n"},
+		{"region=all", "\t// START setup OMIT\n\tx := 1\n\t// END setup OMIT\n\t// START setup2 OMIT\n\ty := 2\n\t// END setup2 OMIT\n\tprintln(x + y)\n"},
+		{"region=shell", "echo hi\n"},
+	}
+	for _, tt := range tests {
+		lo, hi, err := regionToByteRange(tt.addr, []byte(regionSrc))
+		if err != nil {
+			t.Errorf("%s: %v", tt.addr, err)
+			continue
+		}
+		if got := regionSrc[lo:hi]; got != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	for _, addr := range []string{"region=missing", "region=", "region=a b"} {
+		if _, _, err := regionToByteRange(addr, []byte(regionSrc)); err == nil {
+			t.Errorf("%s: got no error", addr)
+		}
+	}
+	if _, _, err := regionToByteRange("region=x", []byte("// START x OMIT\nx\n")); err == nil {
+		t.Error("unterminated region: got no error")
+	}
+}
+
+func TestCodeRegion(t *testing.T) {
+	ctx := &Context{ReadFile: func(string) ([]byte, error) { return []byte(regionSrc), nil }}
+	e, err := parseCode(ctx, "test.slide", 1, ".code main.go region=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(e.(Code).Raw), "\tx := 1\n\ty := 2\n\tprintln(x + y)\n"; got != want {
+		t.Errorf("Raw = %q; want %q", got, want)
+	}
+}
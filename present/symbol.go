@@ -0,0 +1,129 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+)
+
+// symbolRE matches a symbol address such as Config, type:Config or
+// Server.ServeHTTP. Acme addresses never begin with a letter, so the two
+// forms cannot be confused.
+var symbolRE = regexp.MustCompile(`^(?:(func|type|var|const):)?([A-Za-z_]\w*)(?:\.([A-Za-z_]\w*))?$`)
+
+// isSymbolAddr reports whether addr names a Go declaration.
+func isSymbolAddr(addr string) bool {
+	return symbolRE.MatchString(addr)
+}
+
+// symbolToByteRange returns the byte range of the declaration named by addr
+// in the Go source src. The range includes the declaration's doc comment if
+// withDoc is set. A declaration that is part of a parenthesised group is
+// addressed on its own, without the rest of the group.
+func symbolToByteRange(filename string, src []byte, addr string, withDoc bool) (lo, hi int, err error) {
+	m := symbolRE.FindStringSubmatch(addr)
+	if m == nil {
+		return 0, 0, fmt.Errorf("bad symbol address %q", addr)
+	}
+	kind, name, method := m[1], m[2], m[3]
+	if method != "" {
+		if kind != "" && kind != "func" {
+			return 0, 0, fmt.Errorf("bad symbol address %q: only methods have a receiver", addr)
+		}
+		kind = "func"
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	found := func(doc *ast.CommentGroup, node ast.Node) (int, int, error) {
+		lo, hi := offset(node.Pos()), offset(node.End())
+		if withDoc && doc != nil {
+			lo = offset(doc.Pos())
+		}
+		return lo, hi, nil
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if kind != "" && kind != "func" {
+				continue
+			}
+			if method == "" && decl.Recv == nil && decl.Name.Name == name ||
+				method != "" && decl.Recv != nil && decl.Name.Name == method && recvTypeName(decl.Recv) == name {
+				return found(decl.Doc, decl)
+			}
+		case *ast.GenDecl:
+			if method != "" || kind != "" && kind != decl.Tok.String() {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				var doc *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name != name {
+						continue
+					}
+					doc = spec.Doc
+				case *ast.ValueSpec:
+					if !hasName(spec.Names, name) {
+						continue
+					}
+					doc = spec.Doc
+				default:
+					continue
+				}
+				if !decl.Lparen.IsValid() {
+					// An ungrouped declaration: include its keyword.
+					return found(decl.Doc, decl)
+				}
+				return found(doc, spec)
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("no declaration of %s in %s", addr, filename)
+}
+
+// recvTypeName returns the name of the type of a method receiver,
+// without any pointer or type parameters.
+func recvTypeName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	t := recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.ParenExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func hasName(names []*ast.Ident, name string) bool {
+	for _, n := range names {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
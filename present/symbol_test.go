@@ -0,0 +1,87 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"strings"
+	"testing"
+)
+
+const symbolSrc = `package server
+
+// Config configures a Server.
+type Config struct {
+	Addr string
+}
+
+const (
+	// DefaultAddr is the default listen address.
+	DefaultAddr = ":8080"
+	maxConns    = 100
+)
+
+var Verbose bool
+
+// Server serves requests.
+type Server struct{}
+
+// ServeHTTP handles a request.
+func (s *Server) ServeHTTP() {
+	println("serve") // HL
+}
+
+func Config2() {}
+`
+
+func TestSymbolToByteRange(t *testing.T) {
+	tests := []struct {
+		addr    string
+		withDoc bool
+		want    string
+	}{
+		{"Config", false, "type Config struct {\n\tAddr string\n}"},
+		{"type:Config", true, "// Config configures a Server.\ntype Config struct {\n\tAddr string\n}"},
+		{"Server.ServeHTTP", false, "func (s *Server) ServeHTTP() {\n\tprintln(\"serve\") // HL\n}"},
+		{"func:Server.ServeHTTP", true, "// ServeHTTP handles a request.\nfunc (s *Server) ServeHTTP() {\n\tprintln(\"serve\") // HL\n}"},
+		{"DefaultAddr", true, "// DefaultAddr is the default listen address.\n\tDefaultAddr = \":8080\""},
+		{"const:maxConns", false, "maxConns    = 100"},
+		{"var:Verbose", false, "var Verbose bool"},
+		{"Config2", false, "func Config2() {}"},
+	}
+	for _, tt := range tests {
+		lo, hi, err := symbolToByteRange("server.go", []byte(symbolSrc), tt.addr, tt.withDoc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.addr, err)
+			continue
+		}
+		if got := symbolSrc[lo:hi]; got != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	for _, addr := range []string{"Missing", "type:Verbose", "Config.Addr", "type:Server.ServeHTTP"} {
+		if _, _, err := symbolToByteRange("server.go", []byte(symbolSrc), addr, false); err == nil {
+			t.Errorf("%s: got no error", addr)
+		}
+	}
+}
+
+func TestCodeSymbol(t *testing.T) {
+	ctx := &Context{ReadFile: func(string) ([]byte, error) { return []byte(symbolSrc), nil }}
+	e, err := parseCode(ctx, "test.slide", 1, ".code -numbers server.go Server.ServeHTTP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := e.(Code)
+	if want := "func (s *Server) ServeHTTP() {\n\tprintln(\"serve\") // HL\n}\n"; string(code.Raw) != want {
+		t.Errorf("Raw = %q; want %q", code.Raw, want)
+	}
+	if !strings.Contains(string(code.Text), `<span num="20">`) {
+		t.Errorf("Text does not number the first line 20:\n%s", code.Text)
+	}
+	if isSymbolAddr("/^func/,/^}/") || isSymbolAddr("$") {
+		t.Error("acme address taken for a symbol")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+)
+
+// transitionsPrefix introduces the header comment that turns on animated
+// transitions between the code on consecutive slides:
+//   #+codeTransitions=true
+const transitionsPrefix = "#+codeTransitions="
+
+// linkCodeTransitions compares the first code element of each slide with
+// that of the slide before it, if both show the same file, and records, for
+// each of its lines, the line of the earlier code that it continues. The
+// client uses this to animate the lines that are kept, moved, added and
+// removed as the slides change.
+func linkCodeTransitions(sections []Section) error {
+	for i := 1; i < len(sections); i++ {
+		prev, _ := firstCode(sections[i-1].Elem)
+		cur, at := firstCode(sections[i].Elem)
+		if prev == nil || cur == nil || prev.data == nil || cur.data == nil {
+			continue
+		}
+		// Code from unrelated files would be morphed into each other.
+		if prev.FileName != cur.FileName {
+			continue
+		}
+		from, err := diffLines(lineTexts(prev.data.Lines), lineTexts(cur.data.Lines))
+		if err != nil {
+			// Code that changes this much is shown without a transition.
+			continue
+		}
+		for j := range cur.data.Lines {
+			cur.data.Lines[j].From = from[j] + 1
+		}
+		prev.data.TransitionOut = true
+		cur.data.TransitionIn = true
+		for _, c := range []*Code{prev, cur} {
+			var buf bytes.Buffer
+			if err := codeTemplate.Execute(&buf, c.data); err != nil {
+				return err
+			}
+			c.Text = template.HTML(buf.String())
+		}
+		setFirstCode(sections[i-1].Elem, *prev)
+		setElem(sections[i].Elem, at, *cur)
+	}
+	return nil
+}
+
+// firstCode returns a copy of the first code element among elems,
+// looking inside builds, and its index in elems.
+func firstCode(elems []Elem) (*Code, int) {
+	for i, e := range elems {
+		if b, ok := e.(Build); ok {
+			e = b.Elem
+		}
+		if c, ok := e.(Code); ok {
+			return &c, i
+		}
+	}
+	return nil, -1
+}
+
+func setFirstCode(elems []Elem, c Code) {
+	if _, i := firstCode(elems); i >= 0 {
+		setElem(elems, i, c)
+	}
+}
+
+// setElem replaces the element at index i of elems by e, keeping it in
+// its build if it has one.
+func setElem(elems []Elem, i int, e Elem) {
+	if b, ok := elems[i].(Build); ok {
+		b.Elem = e
+		e = b
+	}
+	elems[i] = e
+}
+
+// lineTexts returns the text of the lines without surrounding space, so
+// that reindented lines are matched.
+func lineTexts(lines []codeLine) []string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = strings.TrimSpace(l.L)
+	}
+	return texts
+}
+
+// diffLines returns, for each line of b, the index of the line of a that it
+// corresponds to, or -1 if the line was added. Lines in the longest common
+// subsequence of a and b correspond, as do identical lines that moved.
+func diffLines(a, b []string) ([]int, error) {
+	from := make([]int, len(b))
+	used := make([]bool, len(a))
+	for j := range from {
+		from[j] = -1
+	}
+	ops, err := diffOps(a, b)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if op.kind == diffEqual {
+			from[op.b], used[op.a] = op.a, true
+		}
+	}
+
+	// Pair the remaining identical lines as moves. Blank lines are not
+	// worth animating.
+	for j, l := range b {
+		if from[j] >= 0 || l == "" {
+			continue
+		}
+		for i := range a {
+			if !used[i] && a[i] == l {
+				from[j], used[i] = i, true
+				break
+			}
+		}
+	}
+	return from, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []int
+	}{
+		{"a b c", "a b c", []int{0, 1, 2}},
+		{"a b c", "a x c", []int{0, -1, 2}},
+		{"a b c", "a c", []int{0, 2}},
+		{"a b c", "c a b", []int{2, 0, 1}},
+		{"", "a", []int{-1}},
+	}
+	for _, tt := range tests {
+		got, err := diffLines(strings.Fields(tt.a), strings.Fields(tt.b))
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diffLines(%q, %q) = %v, %v; want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestCodeTransitions(t *testing.T) {
+	files := map[string]string{
+		"f.go": "// START v1 OMIT\nfunc f() {\n\ta()\n}\n// END v1 OMIT\n\n" +
+			"// START v2 OMIT\nfunc f() {\n\tb()\n\ta()\n}\n// END v2 OMIT\n",
+		"g.go": "func g() {\n\ta()\n}\n",
+	}
+	ctx := &Context{ReadFile: func(name string) ([]byte, error) { return []byte(files[name]), nil }}
+	const src = `#+codeTransitions=true
+Title
+
+* One
+
+.code f.go region=v1
+
+* Two
+
+#+build
+.code f.go region=v2
+`
+	doc, err := ctx.Parse(strings.NewReader(src), "test.slide", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := string(doc.Sections[0].Elem[0].(Code).Text)
+	two := string(doc.Sections[1].Elem[0].(Build).Elem.(Code).Text)
+	if !strings.Contains(one, "<pre transition-out>") {
+		t.Errorf("first code is not marked for transition:\n%s", one)
+	}
+	for _, want := range []string{"<pre transition-in>", `<span num="8" from="1">`, `<span num="9">`, `<span num="10" from="2">`, `<span num="11" from="3">`} {
+		if !strings.Contains(two, want) {
+			t.Errorf("second code does not contain %s:\n%s", want, two)
+		}
+	}
+
+	// Without the directive, code is left alone.
+	doc, err = ctx.Parse(strings.NewReader(src[len("#+codeTransitions=true\n"):]), "test.slide", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one := string(doc.Sections[0].Elem[0].(Code).Text); strings.Contains(one, "transition") {
+		t.Errorf("code is marked for transition without the directive:\n%s", one)
+	}
+
+	// Code from another file is not linked to it.
+	doc, err = ctx.Parse(strings.NewReader(src+"\n* Three\n\n.code g.go\n"), "test.slide", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if two := string(doc.Sections[1].Elem[0].(Build).Elem.(Code).Text); strings.Contains(two, "transition-out") {
+		t.Errorf("code is marked for transition to another file:\n%s", two)
+	}
+	if three := string(doc.Sections[2].Elem[0].(Code).Text); strings.Contains(three, "transition") || strings.Contains(three, "from=") {
+		t.Errorf("code of another file is marked for transition:\n%s", three)
+	}
+}
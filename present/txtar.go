@@ -0,0 +1,83 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/txtar"
+)
+
+// archiveFiles parses the txtar archive src, which holds the files of a
+// multi-file program. A comment before the first file is treated as a file
+// named prog.go, as the playground does.
+func archiveFiles(src []byte) ([]txtar.File, error) {
+	a := txtar.Parse(src)
+	files := a.Files
+	if len(bytes.TrimSpace(a.Comment)) > 0 {
+		files = append([]txtar.File{{Name: "prog.go", Data: a.Comment}}, files...)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("archive holds no files")
+	}
+	return files, nil
+}
+
+// archiveCode renders the files of the archive src as a set of tabs, one
+// per file. Each file is preceded by a hidden txtar file marker, so that the
+// text of the element, which the playground sends to be run, is an archive
+// itself.
+func archiveCode(src []byte, steps []highlightStep, edit, numbers bool) (template.HTML, []byte, error) {
+	files, err := archiveFiles(src)
+	if err != nil {
+		return "", nil, err
+	}
+	data := &archiveTemplateData{}
+	raw := &txtar.Archive{}
+	for _, f := range files {
+		lines := codeLines(f.Data, 0, len(f.Data))
+		file := &codeTemplateData{
+			Lines:   formatLines(lines, steps),
+			Prefix:  []byte("-- " + f.Name + " --"),
+			Edit:    edit,
+			Numbers: numbers,
+		}
+		if len(steps) > 1 {
+			file.Steps = len(steps)
+		}
+		highlightLines(file.Lines, filepath.Ext(f.Name))
+
+		var buf bytes.Buffer
+		if err := codeTemplate.Execute(&buf, file); err != nil {
+			return "", nil, err
+		}
+		data.Files = append(data.Files, archiveFile{f.Name, template.HTML(strings.TrimSpace(buf.String()))})
+		raw.Files = append(raw.Files, txtar.File{Name: f.Name, Data: rawCode(lines)})
+	}
+
+	var buf bytes.Buffer
+	if err := archiveTemplate.Execute(&buf, data); err != nil {
+		return "", nil, err
+	}
+	return template.HTML(buf.String()), txtar.Format(raw), nil
+}
+
+type archiveFile struct {
+	Name string
+	Text template.HTML
+}
+
+type archiveTemplateData struct {
+	Files []archiveFile
+}
+
+var archiveTemplate = template.Must(template.New("archive").Parse(strings.TrimSpace(`
+<div class="txtar-tabs">{{range $i, $f := .Files}}<button class="tab{{if not $i}} active{{end}}" file="{{$i}}">{{.Name}}</button>{{end}}</div>
+{{range $i, $f := .Files}}<div class="txtar-file{{if $i}} hidden{{end}}" file="{{$i}}" name="{{.Name}}">{{.Text}}</div>
+{{end}}`)))
@@ -0,0 +1,52 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"strings"
+	"testing"
+)
+
+const archiveSrc = `-- go.mod --
+module example.com/hello
+-- main.go --
+package main
+
+import "example.com/hello/greet"
+
+func main() { greet.Hello() } // HL
+-- greet/greet.go --
+package greet
+`
+
+func TestCodeArchive(t *testing.T) {
+	ctx := &Context{ReadFile: func(string) ([]byte, error) { return []byte(archiveSrc), nil }}
+	e, err := parseCode(ctx, "test.slide", 1, ".play hello.txtar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := e.(Code)
+	if c.Ext != ".txtar" {
+		t.Errorf("got Ext %q, want .txtar", c.Ext)
+	}
+	if string(c.Raw) != archiveSrc {
+		t.Errorf("got Raw\n%s\nwant\n%s", c.Raw, archiveSrc)
+	}
+	text := string(c.Text)
+	for _, want := range []string{
+		`<button class="tab active" file="0">go.mod</button><button class="tab" file="1">main.go</button><button class="tab" file="2">greet/greet.go</button>`,
+		`<div class="txtar-file" file="0" name="go.mod"><pre style="display: none"><span>-- go.mod --</span></pre>`,
+		`<div class="txtar-file hidden" file="2" name="greet/greet.go"><pre style="display: none"><span>-- greet/greet.go --</span></pre>`,
+		`<span num="5"><b><span class="tok-keyword">func</span> main() { greet.Hello() }</b></span>`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %s in\n%s", want, text)
+		}
+	}
+
+	if _, err := parseCode(ctx, "test.slide", 1, ".play hello.txtar /main/"); err == nil {
+		t.Error("address in archive: got no error")
+	}
+}
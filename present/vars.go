@@ -0,0 +1,45 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package present
+
+import (
+	"regexp"
+	"strings"
+)
+
+// varPrefix introduces a header comment that defines a variable:
+//   #+var name=value
+const varPrefix = "#+var "
+
+// varRE matches a variable reference such as {{name}}.
+var varRE = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*}}`)
+
+// setVar records the variable defined by a #+var comment in vars.
+// Comments that do not define a variable are ignored.
+func setVar(vars map[string]string, comment string) {
+	if !strings.HasPrefix(comment, varPrefix) {
+		return
+	}
+	def := strings.SplitN(comment[len(varPrefix):], "=", 2)
+	name := strings.TrimSpace(def[0])
+	if len(def) != 2 || name == "" {
+		return
+	}
+	vars[name] = strings.TrimSpace(def[1])
+}
+
+// expandVars replaces the variable references in s with their values.
+// References to undefined variables are left untouched.
+func expandVars(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return varRE.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := vars[varRE.FindStringSubmatch(ref)[1]]; ok {
+			return v
+		}
+		return ref
+	})
+}
@@ -0,0 +1,24 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup bounds how long cmd is waited for once it is killed or
+// has exited. Windows has no process groups, so the processes it starts
+// are not killed with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = waitDelay
+}
+
+// killProcessGroup kills p.
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
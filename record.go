@@ -0,0 +1,130 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/davelaursen/present-plus/present"
+)
+
+// recordFiles implements the record command, which runs the .play snippets
+// of each of the named present files and stores their output in the file's
+// recording, to be played back when they cannot be run live. It returns the
+// exit status of the command.
+func recordFiles(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: present-plus record files...")
+		return 2
+	}
+	status := 0
+	for _, name := range args {
+		if err := record(name); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			status = 2
+		}
+	}
+	return status
+}
+
+// record runs the snippets of the named present file and writes their
+// output to its recording, replacing any earlier recording. The snippets
+// are run even if the file's playground policy does not let them run in
+// the browser, as that is when the recording is played back.
+func record(name string) error {
+	doc, err := parse(name, 0)
+	if err != nil {
+		return err
+	}
+	bc := fileBuildContext(name, doc.Play)
+	bc.Enabled, bc.Extensions = true, nil
+	recording := make(map[string][]recordedEvent)
+	for _, s := range doc.Sections {
+		for _, c := range playCode(s.Elem) {
+			key := recordKey(c)
+			if _, ok := recording[key]; ok {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s:%d: running %s\n", c.File, c.Line, c.FileName)
+			recording[key] = run(c.Program(), &Options{Ext: c.Ext}, bc)
+		}
+	}
+	if len(recording) == 0 {
+		return fmt.Errorf("no runnable snippets")
+	}
+	b, err := json.MarshalIndent(recording, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(recordingFile(name), append(b, '\n'), 0666)
+}
+
+// playCode returns the code written with .play among elems and the
+// elements they contain.
+func playCode(elems []present.Elem) []present.Code {
+	var code []present.Code
+	for _, e := range elems {
+		switch e := e.(type) {
+		case present.Code:
+			if e.PlayCmd {
+				code = append(code, e)
+			}
+		case present.Build:
+			code = append(code, playCode([]present.Elem{e.Elem})...)
+		case present.Section:
+			code = append(code, playCode(e.Elem)...)
+		}
+	}
+	return code
+}
+
+// run runs the program as the playground does and returns its output.
+func run(program string, opt *Options, bc buildContext) []recordedEvent {
+	// The program's output is read while it is being built. It is
+	// given no input.
+	out := make(chan *Message)
+	go func() {
+		startProcess("", program, out, opt, bc).Input("", true)
+	}()
+	var events []recordedEvent
+	last := time.Now()
+	for m := range out {
+		now := time.Now()
+		events = append(events, recordedEvent{
+			Kind:  m.Kind,
+			Body:  m.Body,
+			Delay: int(now.Sub(last) / time.Millisecond),
+		})
+		last = now
+		if m.Kind == "end" {
+			if m.Body != "" {
+				fmt.Fprintf(os.Stderr, "\tprogram exited: %s\n", m.Body)
+			}
+			break
+		}
+	}
+	return events
+}
+
+// recordingFile returns the name of the file that holds the recorded output
+// of the snippets of the named present file. The client fetches it from
+// beside the file.
+func recordingFile(name string) string {
+	return name + ".play.json"
+}
+
+// A recordedEvent is an output message from a run of a snippet, and the
+// time in milliseconds since the message before it.
+type recordedEvent struct {
+	Kind  string // "stdout", "stderr" or "end"
+	Body  string
+	Delay int
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// fileArg stands for the file holding the snippet in the command of a
+// runner.
+const fileArg = "{file}"
+
+// runner returns the command that runs the snippets from files with the
+// extension ext, as registered in the playRunners of the document's
+// directory, or nil if there is none and snippets are run as Go programs
+// or scripts. A relative path to the command is resolved against the
+// document's directory.
+func (bc buildContext) runner(ext string) []string {
+	r := bc.runners[ext]
+	if ext == "" || len(r) == 0 {
+		return nil
+	}
+	args := append([]string(nil), r...)
+	if !filepath.IsAbs(args[0]) && strings.ContainsAny(args[0], `/\`) {
+		if abs, err := filepath.Abs(filepath.Join(bc.dir, args[0])); err == nil {
+			args[0] = abs
+		}
+	}
+	return args
+}
+
+// startRunner writes body to a file with the extension ext and starts the
+// runner command args on it, sending its output to p.out. The name of the
+// file replaces {file} in the arguments, or is added to them if they do
+// not mention it.
+func (p *process) startRunner(args []string, ext, body string) error {
+	dir, err := p.tempDir()
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(dir, "prog"+ext)
+	if err := ioutil.WriteFile(file, []byte(body), 0666); err != nil {
+		return err
+	}
+	named := false
+	for i, a := range args[1:] {
+		if strings.Contains(a, fileArg) {
+			args[i+1] = strings.Replace(a, fileArg, file, -1)
+			named = true
+		}
+	}
+	if !named {
+		args = append(args, file)
+	}
+	cmd := p.cmd(dir, cpuLimited(args)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go p.feed(stdin)
+	p.run = cmd
+	return nil
+}
@@ -0,0 +1,688 @@
+// Copyright 2012 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !appengine
+
+package main
+
+// This file is stolen from golang.org/x/tools/playground/socket.
+// It implements the WebSocket-based playground backend. Clients connect to
+// the handler and send run/kill commands, and the server sends the output
+// and exit status of the running processes. The wire format is JSON and is
+// described by the Message type.
+// The changes from the original are in how programs are built: a txtar
+// archive may hold a whole module, with a go.mod, several packages and
+// tests, and the module is built, or tested, as a whole. Snippets in other
+// languages are run by the runners of runner.go. Runs are also
+// bounded by the limits in limits.go, and Native Client is not supported.
+// Clients may also send a program its standard input.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/websocket"
+	"golang.org/x/tools/txtar"
+)
+
+// runScripts specifies whether the socket handler should execute shell
+// scripts (snippets that start with a shebang).
+var runScripts = true
+
+const (
+	// The maximum number of messages to send per session (avoid flooding).
+	msgLimit = 1000
+
+	// Batch messages sent in this interval and send as a single message.
+	msgDelay = 10 * time.Millisecond
+
+	// The maximum number of input messages held for a program that has
+	// not read them yet.
+	inputLimit = 100
+)
+
+// Message is the wire format for the websocket connection to the browser.
+// It is used for both sending output messages and receiving commands, as
+// distinguished by the Kind field.
+type Message struct {
+	Id      string // client-provided unique id for the process
+	Kind    string // in: "run", "kill", "stdin", "eof" out: "stdout", "stderr", "end"
+	Body    string
+	Options *Options `json:",omitempty"`
+}
+
+// Options specify additional message options.
+type Options struct {
+	Race bool   // use -race flag when building code (for "run" only)
+	Doc  string // URL path of the present file the code is from (for "run" only)
+	Ext  string // extension of the file the code is from, which selects its runner (for "run" only)
+}
+
+// newSocketHandler returns a websocket server which checks the origin of
+// requests.
+func newSocketHandler(origin *url.URL) websocket.Server {
+	return websocket.Server{
+		Config:    websocket.Config{Origin: origin},
+		Handshake: handshake,
+		Handler:   websocket.Handler(socketHandler),
+	}
+}
+
+// handshake checks the origin of a request during the websocket handshake.
+func handshake(c *websocket.Config, req *http.Request) error {
+	o, err := websocket.Origin(c, req)
+	if err != nil {
+		log.Println("bad websocket origin:", err)
+		return websocket.ErrBadWebSocketOrigin
+	}
+	if !sameOrigin(c.Origin, o) {
+		log.Println("bad websocket origin:", o)
+		return websocket.ErrBadWebSocketOrigin
+	}
+	log.Println("accepting connection from:", req.RemoteAddr)
+	return nil
+}
+
+// sameOrigin reports whether the URL u is of the web origin origin. The
+// port of u may be left out.
+func sameOrigin(origin, u *url.URL) bool {
+	_, port, err := net.SplitHostPort(origin.Host)
+	if err != nil {
+		return false
+	}
+	return origin.Scheme == u.Scheme && (origin.Host == u.Host || origin.Host == net.JoinHostPort(u.Host, port))
+}
+
+// socketHandler handles the websocket connection for a given present session.
+// It handles transcoding Messages to and from JSON format, and starting
+// and killing processes.
+func socketHandler(c *websocket.Conn) {
+	in, out := make(chan *Message), make(chan *Message)
+	errc := make(chan error, 1)
+
+	// Decode messages from client and send to the in channel.
+	go func() {
+		dec := json.NewDecoder(c)
+		for {
+			var m Message
+			if err := dec.Decode(&m); err != nil {
+				errc <- err
+				return
+			}
+			in <- &m
+		}
+	}()
+
+	// Receive messages from the out channel and encode to the client.
+	go func() {
+		enc := json.NewEncoder(c)
+		for m := range out {
+			if err := enc.Encode(m); err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+	defer close(out)
+
+	// Start and kill processes and handle errors.
+	proc := make(map[string]*process)
+	for {
+		select {
+		case m := <-in:
+			switch m.Kind {
+			case "run":
+				log.Println("running snippet from:", c.Request().RemoteAddr)
+				proc[m.Id].Kill()
+				var doc string
+				if m.Options != nil {
+					doc = m.Options.Doc
+				}
+				proc[m.Id] = startProcess(m.Id, m.Body, out, m.Options, docBuildContext(doc))
+			case "kill":
+				proc[m.Id].Kill()
+			case "stdin":
+				proc[m.Id].Input(m.Body, false)
+			case "eof":
+				proc[m.Id].Input("", true)
+			}
+		case err := <-errc:
+			if err != io.EOF {
+				// A encode or decode has failed; bail.
+				log.Println(err)
+			}
+			// Shut down any running processes.
+			for _, p := range proc {
+				p.Kill()
+			}
+			return
+		}
+	}
+}
+
+// process represents a running process.
+type process struct {
+	out  chan<- *Message
+	done chan struct{} // closed when wait completes
+	run  *exec.Cmd
+	path string
+	slot bool     // whether the process holds one of the runSlots
+	env  []string // environment variables set for the commands run
+
+	// The standard input of the program, as sent by the client. It is
+	// closed when the client sends "eof".
+	input chan string
+	eof   bool
+
+	// The context of the commands run for the process. It is cancelled
+	// when the process times out or exceeds its output limit.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	written int   // bytes of output sent
+	stopped error // why the process was stopped early, if it was
+}
+
+// startProcess builds and runs the given program, sending its output
+// and end event as Messages on the provided channel.
+func startProcess(id, body string, dest chan<- *Message, opt *Options, bc buildContext) *process {
+	var (
+		done = make(chan struct{})
+		out  = make(chan *Message)
+		p    = &process{out: out, done: done, input: make(chan string, inputLimit)}
+	)
+	if limits.Timeout > 0 {
+		p.ctx, p.cancel = context.WithTimeout(context.Background(), limits.Timeout)
+	} else {
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+	}
+	go func() {
+		defer close(done)
+		for m := range buffer(limiter(out, p)) {
+			m.Id = id
+			dest <- m
+		}
+	}()
+	if !bc.Enabled {
+		p.end(errors.New("the playground is turned off for this document"))
+		return nil
+	}
+	// Snippets sent without their extension, as by playground.js, are Go.
+	ext := ".go"
+	if opt != nil && opt.Ext != "" {
+		ext = opt.Ext
+	}
+	if !bc.Allows(ext) {
+		p.end(fmt.Errorf("%s files may not be run from this document", ext))
+		return nil
+	}
+	if err := bc.check(body, ext); err != nil {
+		p.end(err)
+		return nil
+	}
+	var err error
+	if runSlots != nil {
+		select {
+		case runSlots <- struct{}{}:
+			p.slot = true
+		default:
+			p.end(errors.New("too many programs are running; try again later"))
+			return nil
+		}
+	}
+	if args := bc.runner(ext); args != nil {
+		err = p.startRunner(args, ext, body)
+	} else if path, args := shebang(body); path != "" && ext != ".go" && ext != ".txtar" {
+		// Go snippets are built as Go whatever they start with, so that
+		// a script cannot be sent as one to get around the policy.
+		if runScripts {
+			err = p.startProcess(path, args, body)
+		} else {
+			err = errors.New("script execution is not allowed")
+		}
+	} else {
+		err = p.start(body, opt, bc)
+	}
+	if err != nil {
+		p.end(err)
+		return nil
+	}
+	go func() {
+		err := p.run.Wait()
+		// Kill what the program left running in the background, which
+		// kept its output open until waitDelay.
+		killProcessGroup(p.run.Process)
+		if err == exec.ErrWaitDelay {
+			err = nil
+		}
+		if s := p.run.ProcessState; err != nil && limits.CPUTime > 0 && s.UserTime()+s.SystemTime() >= limits.CPUTime {
+			err = fmt.Errorf("used more than %v of CPU time", limits.CPUTime)
+		}
+		p.end(err)
+	}()
+	return p
+}
+
+// end sends an "end" message to the client, containing the process id and the
+// given error value, or the reason the process was stopped. It also removes
+// the process' temporary directory, if present, and releases its run slot.
+func (p *process) end(err error) {
+	if p.path != "" {
+		defer os.RemoveAll(p.path)
+	}
+	if p.slot {
+		defer func() { <-runSlots }()
+	}
+	p.cancel()
+	p.mu.Lock()
+	if p.stopped != nil {
+		err = p.stopped
+	} else if err != nil && p.ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", limits.Timeout)
+	}
+	p.mu.Unlock()
+	m := &Message{Kind: "end"}
+	if err != nil {
+		m.Body = err.Error()
+	}
+	p.out <- m
+	close(p.out)
+}
+
+// limiter returns a channel that wraps the given channel.
+// It receives Messages from the given channel and sends them to the returned
+// channel until it passes msgLimit messages, at which point it will kill the
+// process and pass only the "end" message.
+// When the given channel is closed, or when the "end" message is received,
+// it closes the returned channel.
+func limiter(in <-chan *Message, p *process) <-chan *Message {
+	out := make(chan *Message)
+	go func() {
+		defer close(out)
+		n := 0
+		for m := range in {
+			switch {
+			case n < msgLimit || m.Kind == "end":
+				out <- m
+				if m.Kind == "end" {
+					return
+				}
+			case n == msgLimit:
+				// Kill in a goroutine as Kill will not return
+				// until the process' output has been
+				// processed, and we're doing that in this loop.
+				go p.Kill()
+			default:
+				continue // don't increment
+			}
+			n++
+		}
+	}()
+	return out
+}
+
+// buffer returns a channel that wraps the given channel. It receives messages
+// from the given channel and sends them to the returned channel.
+// Message bodies are gathered over the period msgDelay and coalesced into a
+// single Message before they are passed on. Messages of the same kind are
+// coalesced; when a message of a different kind is received, any buffered
+// messages are flushed. When the given channel is closed, buffer flushes the
+// remaining buffered messages and closes the returned channel.
+func buffer(in <-chan *Message) <-chan *Message {
+	out := make(chan *Message)
+	go func() {
+		defer close(out)
+		var (
+			tc    <-chan time.Time
+			buf   []byte
+			kind  string
+			flush = func() {
+				if len(buf) == 0 {
+					return
+				}
+				out <- &Message{Kind: kind, Body: safeString(buf)}
+				buf = buf[:0] // recycle buffer
+				kind = ""
+			}
+		)
+		for {
+			select {
+			case m, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				if m.Kind == "end" {
+					flush()
+					out <- m
+					return
+				}
+				if kind != m.Kind {
+					flush()
+					kind = m.Kind
+					if tc == nil {
+						tc = time.After(msgDelay)
+					}
+				}
+				buf = append(buf, m.Body...)
+			case <-tc:
+				flush()
+				tc = nil
+			}
+		}
+	}()
+	return out
+}
+
+// Kill stops the process if it is running, with the processes it started,
+// and waits for it to exit.
+func (p *process) Kill() {
+	if p == nil || p.run == nil {
+		return
+	}
+	killProcessGroup(p.run.Process)
+	<-p.done // block until process exits
+}
+
+// Input passes s to the standard input of the process, or closes its
+// standard input if eof is set. Input sent while the program is being built
+// is held until it runs; input to a program that does not read it, such as a
+// test or a script, is dropped once inputLimit messages are waiting.
+func (p *process) Input(s string, eof bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.eof {
+		return
+	}
+	if eof {
+		p.eof = true
+		close(p.input)
+		return
+	}
+	select {
+	case p.input <- s:
+	default:
+		log.Println("dropping input to a program that does not read it")
+	}
+}
+
+// feed writes the input of the process to w, the standard input of its
+// program, until the input is closed or the process ends.
+func (p *process) feed(w io.WriteCloser) {
+	defer w.Close()
+	for {
+		select {
+		case s, ok := <-p.input:
+			if !ok {
+				return
+			}
+			if _, err := io.WriteString(w, s); err != nil {
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// shebang looks for a shebang ('#!') at the beginning of the passed string.
+// If found, it returns the path and args after the shebang.
+// args includes the command as args[0].
+func shebang(body string) (path string, args []string) {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "#!") {
+		return "", nil
+	}
+	if i := strings.Index(body, "\n"); i >= 0 {
+		body = body[:i]
+	}
+	fs := strings.Fields(body[2:])
+	if len(fs) == 0 {
+		return "", nil
+	}
+	return fs[0], fs
+}
+
+// startProcess starts a given program given its path and passing the given body
+// to the command standard input.
+func (p *process) startProcess(path string, args []string, body string) error {
+	dir, err := p.tempDir()
+	if err != nil {
+		return err
+	}
+	cmd := p.cmd(dir, cpuLimited(append([]string{path}, args[1:]...))...)
+	cmd.Stdin = strings.NewReader(body)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.run = cmd
+	return nil
+}
+
+// tempDir creates the temporary directory of the process, which is removed
+// by p.end. Its tmp subdirectory is used as the TMPDIR of the commands run.
+func (p *process) tempDir() (string, error) {
+	path, err := ioutil.TempDir(limits.TempDir, "present-")
+	if err != nil {
+		return "", err
+	}
+	p.path = path
+	return path, os.Mkdir(filepath.Join(path, "tmp"), 0777)
+}
+
+// start builds and starts the given program, sending its output to p.out,
+// and stores the running *exec.Cmd in the run field.
+func (p *process) start(body string, opt *Options, bc buildContext) error {
+	// We "go build" and then exec the binary so that the
+	// resultant *exec.Cmd is a handle to the user's program
+	// (rather than the go tool process).
+	// This makes Kill work.
+
+	path, err := p.tempDir()
+	if err != nil {
+		return err
+	}
+
+	out := "prog"
+	if runtime.GOOS == "windows" {
+		out = "prog.exe"
+	}
+	bin := filepath.Join(path, "bin", out)
+
+	// Write the files of the program to path/src.
+	src := filepath.Join(path, "src")
+	files, err := writeProgram(src, body)
+	if err != nil {
+		return err
+	}
+	b, err := bc.build(src, files)
+	if err != nil {
+		return err
+	}
+	p.env = b.env
+	if b.overlay != nil {
+		overlay := filepath.Join(path, "overlay.json")
+		if err := writeOverlay(overlay, b.overlay); err != nil {
+			return err
+		}
+		b.flags = append(b.flags, "-overlay", overlay)
+	}
+
+	var args []string
+	if b.tests {
+		// The module has tests: test all of its packages. The go tool
+		// is the process, and Kill stops it and the tests with it, as
+		// they are in its process group.
+		args = append([]string{b.goCmd, "test"}, b.flags...)
+		if limits.Timeout > 0 {
+			args = append(args, "-timeout="+limits.Timeout.String())
+		}
+	} else {
+		args = append([]string{b.goCmd, "build"}, b.flags...)
+	}
+	if opt != nil && opt.Race {
+		p.out <- &Message{
+			Kind: "stderr",
+			Body: "Running with race detector.\n",
+		}
+		args = append(args, "-race")
+	}
+	if b.tests {
+		cmd := p.cmd(b.dir, cpuLimited(append(args, b.pkgs...))...)
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		p.run = cmd
+		return nil
+	}
+
+	// build the main package, creating bin
+	cmd := p.cmd(b.dir, append(append(args, "-o", bin), b.pkgs[0])...)
+	cmd.Stdout = cmd.Stderr // send compiler output to stderr
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+
+	// run bin
+	cmd = p.cmd(path, cpuLimited([]string{bin})...)
+	if opt != nil && opt.Race {
+		cmd.Env = append(cmd.Env, "GOMAXPROCS=2")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		// If we failed to exec, that might be because they built
+		// a non-main package instead of an executable.
+		// Check and report that.
+		if name, err := packageName(body); err == nil && name != "main" {
+			return errors.New(`executable programs must use "package main"`)
+		}
+		return err
+	}
+	go p.feed(stdin)
+	p.run = cmd
+	return nil
+}
+
+// writeProgram writes the program in body to the directory dir. The body is
+// either a single Go file or a txtar archive of the files of a module, which
+// may be in subdirectories; text before the first file of an archive is a
+// file named prog.go. It returns the slash-separated names of the files.
+func writeProgram(dir, body string) ([]string, error) {
+	a := txtar.Parse([]byte(body))
+	if len(bytes.TrimSpace(a.Comment)) > 0 {
+		a.Files = append(a.Files, txtar.File{Name: "prog.go", Data: a.Comment})
+	}
+	var names []string
+	for _, f := range a.Files {
+		name := path.Clean(f.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, `\`) {
+			return nil, errors.New("invalid file name in archive: " + f.Name)
+		}
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(file, f.Data, 0666); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// cmd builds an *exec.Cmd that writes its standard output and error to the
+// process' output channel. The command is killed, with the processes it
+// starts, when the process times out, and its environment is scrubbed.
+func (p *process) cmd(dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(p.ctx, args[0], args[1:]...)
+	setProcessGroup(cmd)
+	cmd.Dir = dir
+	tmp := filepath.Join(p.path, "tmp")
+	cmd.Env = environ(append([]string{"TMPDIR=" + tmp, "TMP=" + tmp, "TEMP=" + tmp}, p.env...)...)
+	cmd.Stdout = &messageWriter{kind: "stdout", p: p}
+	cmd.Stderr = &messageWriter{kind: "stderr", p: p}
+	return cmd
+}
+
+func packageName(body string) (string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "prog.go",
+		strings.NewReader(body), parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	return f.Name.String(), nil
+}
+
+// messageWriter is an io.Writer that converts all writes to Message sends on
+// the process' out channel with the specified kind. Once the process has
+// written limits.MaxOutput bytes, the rest of its output is dropped and it
+// is stopped.
+type messageWriter struct {
+	kind string
+	p    *process
+}
+
+func (w *messageWriter) Write(b []byte) (n int, err error) {
+	p := w.p
+	p.mu.Lock()
+	n = len(b)
+	if limits.MaxOutput > 0 {
+		if p.stopped != nil {
+			p.mu.Unlock()
+			return n, nil
+		}
+		if left := limits.MaxOutput - p.written; len(b) > left {
+			b = b[:left]
+			p.stopped = fmt.Errorf("output exceeded %d bytes", limits.MaxOutput)
+			p.cancel()
+		}
+		p.written += len(b)
+	}
+	p.mu.Unlock()
+	if len(b) > 0 {
+		p.out <- &Message{Kind: w.kind, Body: safeString(b)}
+	}
+	return n, nil
+}
+
+// safeString returns b as a valid UTF-8 string.
+func safeString(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	var buf bytes.Buffer
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,376 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Playback of the recorded output of .play snippets. The output is recorded
+// by "present-plus record" in a file beside the present file, keyed by the
+// data-record attribute of each code element. It is played back when the
+// playground is turned off, or when it cannot be reached.
+//
+// The transport used with the socket also lets the presenter type the
+// standard input of a running program.
+
+var recordings = null;
+
+// loadRecordings fetches the recording of the current document, if it has
+// one, and calls done when it is loaded.
+function loadRecordings(done) {
+  var req = new XMLHttpRequest();
+  req.open('GET', window.location.pathname + '.play.json');
+  req.onload = function() {
+    if (req.status == 200) {
+      try {
+        recordings = JSON.parse(req.responseText);
+      } catch (e) {
+        console.log('bad recording: ' + e);
+      }
+    }
+    done();
+  };
+  req.onerror = done;
+  req.send();
+};
+
+// playText returns the program run for a code element, as play.js does.
+function playText(node) {
+  var s = '';
+  for (var i = 0; i < node.childNodes.length; i++) {
+    var n = node.childNodes[i];
+    if (n.nodeType === 1) {
+      if (n.tagName === 'BUTTON') continue;
+      if (n.tagName === 'SPAN' && n.className === 'number') continue;
+      if (n.tagName === 'DIV' || n.tagName === 'BR' || n.tagName === 'PRE') {
+        s += '\n';
+      }
+      s += playText(n);
+      continue;
+    }
+    if (n.nodeType === 3) {
+      s += n.nodeValue;
+    }
+  }
+  return s.replace('\xA0', ' ');
+};
+
+// findRecording returns the recorded output of the playground code whose
+// program is body, or null if the code has been edited since or was not
+// recorded.
+function findRecording(body) {
+  if (!recordings) return null;
+  var code = document.querySelectorAll('div.playground[data-record]');
+  for (var i = 0, c; c = code[i]; i++) {
+    var events = recordings[c.getAttribute('data-record')];
+    if (events && playText(c) == body) {
+      return events;
+    }
+  }
+  return null;
+};
+
+// playRecording writes the recorded events to output, at the pace they were
+// recorded. It returns a handle with a Kill method, like a transport's Run.
+function playRecording(events, output) {
+  var i = 0;
+  var timer = null;
+  function next() {
+    timer = null;
+    if (i >= events.length) return;
+    var e = events[i++];
+    output({ Kind: e.Kind, Body: e.Body });
+    if (i < events.length) {
+      timer = window.setTimeout(next, events[i].Delay);
+    }
+  }
+  output({ Kind: 'start' });
+  output({ Kind: 'system', Body: 'Playing recorded output.\n' });
+  timer = window.setTimeout(next, events.length ? events[0].Delay : 0);
+  return {
+    Kill: function() {
+      if (timer === null) return;
+      window.clearTimeout(timer);
+      timer = null;
+      output({ Kind: 'end', Body: 'killed' });
+    }
+  };
+};
+
+// HTTP_UNAVAILABLE is the error HTTPTransport writes when it cannot reach
+// the server.
+var HTTP_UNAVAILABLE = 'Error communicating with remote server.';
+
+// RecordingTransport wraps a playground transport, playing back the recorded
+// output of a snippet if the transport reports that it is unavailable.
+function RecordingTransport(live) {
+  return {
+    Run: function(body, output, options) {
+      var events = findRecording(body);
+      var fellBack = false;
+      var running = live.Run(body, function(write) {
+        if (fellBack) {
+          return;
+        }
+        var unavailable = write.Kind == 'unavailable' ||
+            write.Kind == 'stderr' && write.Body == HTTP_UNAVAILABLE;
+        if (!unavailable) {
+          output(write);
+          return;
+        }
+        fellBack = true;
+        if (events) {
+          running = playRecording(events, output);
+        } else {
+          output({ Kind: 'start' });
+          output({ Kind: 'end', Body: 'the playground is unavailable' });
+        }
+      }, options);
+      return {
+        Kill: function() {
+          running.Kill();
+        }
+      };
+    }
+  };
+};
+
+// outputPanel returns the output panel of the playground code element c.
+// play.js adds the run button, then the output panel, after the code.
+function outputPanel(c) {
+  var p = c.nextSibling && c.nextSibling.nextSibling;
+  return p && p.classList.contains('output') ? p : null;
+};
+
+// runningCode returns the playground code element whose program, body, is
+// being run, or null if there is none. play.js shows the output panel of
+// the code before running it.
+function runningCode(body) {
+  var code = document.querySelectorAll('div.playground');
+  for (var i = 0, c; c = code[i]; i++) {
+    var p = outputPanel(c);
+    if (p && p.style.display == 'block' && playText(c) == body) {
+      return c;
+    }
+  }
+  return null;
+};
+
+// inputBox adds a box for the standard input of a program to the output
+// panel of the code element it is run from. Each line typed is passed to
+// send and echoed in the output; Ctrl-D calls eof. It returns a handle whose
+// remove method takes the box away again.
+function inputBox(code, send, eof) {
+  var panel = code && outputPanel(code);
+  if (!panel) {
+    return { remove: function() {} };
+  }
+  var outpre = panel.querySelector('pre');
+  var input = document.createElement('input');
+  input.className = 'stdin';
+  input.type = 'text';
+  input.placeholder = 'Input: Enter sends a line, Ctrl-D ends the input';
+  input.addEventListener('keydown', function(e) {
+    // Keys typed here are not for the slides.
+    e.stopPropagation();
+    if (e.keyCode == 13) {
+      var line = input.value + '\n';
+      input.value = '';
+      send(line);
+      var span = document.createElement('span');
+      span.className = 'stdin';
+      span.textContent = line;
+      outpre.appendChild(span);
+      outpre.scrollTop = outpre.scrollHeight;
+      e.preventDefault();
+    } else if (e.keyCode == 68 && e.ctrlKey) {
+      eof();
+      input.disabled = true;
+      e.preventDefault();
+    }
+  }, false);
+  panel.appendChild(input);
+  panel.classList.add('interactive');
+  input.focus();
+  return {
+    remove: function() {
+      if (input.parentNode) input.parentNode.removeChild(input);
+      panel.classList.remove('interactive');
+    }
+  };
+};
+
+// PlusSocketTransport is the SocketTransport of playground.js, changed to
+// report that it is unavailable when its connection is not open or closes
+// while a program runs, to tell the server which document a program is
+// from and which language it is in, and to send the program the input
+// typed in its output panel.
+function PlusSocketTransport() {
+  var id = 0;
+  var outputs = {};
+  var pending = [];
+  var websocket = null;
+  if (window.location.protocol == 'http:') {
+    websocket = new WebSocket('ws://' + window.location.host + '/socket');
+  } else if (window.location.protocol == 'https:') {
+    websocket = new WebSocket('wss://' + window.location.host + '/socket');
+  }
+
+  function unavailable(id) {
+    var output = outputs[id];
+    delete outputs[id];
+    if (output) output({ Kind: 'unavailable' });
+  }
+
+  if (websocket) {
+    websocket.onopen = function() {
+      for (var i = 0; i < pending.length; i++) {
+        websocket.send(pending[i]);
+      }
+      pending = [];
+    };
+    websocket.onclose = function() {
+      console.log('websocket connection closed');
+      for (var id in outputs) {
+        unavailable(id);
+      }
+    };
+    websocket.onmessage = function(e) {
+      var m = JSON.parse(e.data);
+      var output = outputs[m.Id];
+      if (!output) return;
+      output({ Kind: m.Kind, Body: m.Body });
+      if (m.Kind == 'end') {
+        delete outputs[m.Id];
+      }
+    };
+  }
+
+  function send(m) {
+    var s = JSON.stringify(m);
+    if (websocket && websocket.readyState == WebSocket.CONNECTING) {
+      pending.push(s);
+      return true;
+    }
+    if (!websocket || websocket.readyState != WebSocket.OPEN) {
+      return false;
+    }
+    websocket.send(s);
+    return true;
+  }
+
+  return {
+    Run: function(body, output, options) {
+      var thisID = id + '';
+      id++;
+      var code = runningCode(body);
+      var input = inputBox(code, function(s) {
+        send({ Id: thisID, Kind: 'stdin', Body: s });
+      }, function() {
+        send({ Id: thisID, Kind: 'eof' });
+      });
+      outputs[thisID] = function(write) {
+        if (write.Kind == 'end' || write.Kind == 'unavailable') {
+          input.remove();
+        }
+        output(write);
+      };
+      // Start the output now, not when the program first writes, so
+      // that input echoed before then is kept.
+      output({ Kind: 'start' });
+      // The server builds the code as the document it is from says, and
+      // runs code in other languages than Go with the runner registered
+      // for its file's extension.
+      options = options || {};
+      options.Doc = window.location.pathname;
+      options.Ext = code ? code.getAttribute('data-ext') : '';
+      if (!send({ Id: thisID, Kind: 'run', Body: body, Options: options })) {
+        unavailable(thisID);
+      }
+      return {
+        Kill: function() {
+          input.remove();
+          delete outputs[thisID];
+          send({ Id: thisID, Kind: 'kill' });
+        }
+      };
+    }
+  };
+};
+
+// addPlayback adds a button that plays back the recorded output of a code
+// element that cannot be run, and an output panel like that of play.js.
+function addPlayback(code, events) {
+  var output = document.createElement('div');
+  var outpre = document.createElement('pre');
+  var running = null;
+
+  function onRun() {
+    if (running) running.Kill();
+    output.style.display = 'block';
+    outpre.textContent = '';
+    run1.style.display = 'none';
+    running = playRecording(events, PlaygroundOutput(outpre));
+  }
+  function onClose() {
+    if (running) running.Kill();
+    output.style.display = 'none';
+    run1.style.display = 'inline-block';
+  }
+  function button(className, label, onClick) {
+    var b = document.createElement('button');
+    b.className = className;
+    b.textContent = label;
+    b.addEventListener('click', onClick, false);
+    return b;
+  }
+
+  var run1 = button('run', 'Run', onRun);
+  var buttons1 = document.createElement('div');
+  buttons1.className = 'buttons';
+  buttons1.appendChild(run1);
+  code.parentNode.insertBefore(buttons1, code.nextSibling);
+
+  var buttons2 = document.createElement('div');
+  buttons2.className = 'buttons';
+  buttons2.appendChild(button('run', 'Run', onRun));
+  buttons2.appendChild(button('close', 'Close', onClose));
+  output.className = 'output';
+  output.appendChild(buttons2);
+  output.appendChild(outpre);
+  output.style.display = 'none';
+  code.parentNode.insertBefore(output, buttons1.nextSibling);
+  code.classList.add('recorded');
+};
+
+// PlaygroundOutput is defined by playground.js, which is not loaded when
+// the playground is turned off.
+if (typeof PlaygroundOutput === 'undefined') {
+  var PlaygroundOutput = function(el) {
+    return function(write) {
+      if (write.Kind == 'start') {
+        el.innerHTML = '';
+        return;
+      }
+      var m = write.Body;
+      if (write.Kind == 'end') {
+        m = '\nProgram exited' + (m ? ': ' + m : '.');
+      }
+      var span = document.createElement('span');
+      span.className = write.Kind == 'stdout' || write.Kind == 'stderr' ? write.Kind : 'system';
+      span.textContent = m;
+      el.appendChild(span);
+      el.scrollTop = el.scrollHeight;
+    };
+  };
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+  loadRecordings(function() {
+    if (!recordings) return;
+    var code = document.querySelectorAll('div.code[data-record]:not(.playground)');
+    for (var i = 0, c; c = code[i]; i++) {
+      var events = recordings[c.getAttribute('data-record')];
+      if (events) {
+        addPlayback(c, events);
+      }
+    }
+  });
+}, false);
@@ -204,8 +204,195 @@ function updateSlides() {
   updateHash();
 };
 
+function getBuildItems(no) {
+  var el = getSlideEl(no);
+  if (!el) {
+    return [];
+  }
+
+  // Lists are built one item at a time; other elements as a whole.
+  return el.querySelectorAll('.build > :not(ul), .build > ul > li');
+};
+
+function buildNextItem() {
+  var toBuild = getSlideEl(curSlide).querySelectorAll('.to-build');
+
+  if (!toBuild.length) {
+    return false;
+  }
+
+  toBuild[0].classList.remove('to-build');
+  return true;
+};
+
+function buildPrevItem() {
+  var items = getBuildItems(curSlide);
+
+  for (var i = items.length - 1; i >= 0; i--) {
+    if (!items[i].classList.contains('to-build')) {
+      items[i].classList.add('to-build');
+      return true;
+    }
+  }
+  return false;
+};
+
+/* Code walkthroughs: code with several highlight steps moves its highlight
+   from one step to the next before the slide changes. */
+
+function getSteppedCode(no) {
+  var el = getSlideEl(no);
+  if (!el) {
+    return [];
+  }
+  return el.querySelectorAll('pre[steps]');
+};
+
+function showCodeStep(pre, step) {
+  pre.setAttribute('step', step);
+  var lines = pre.querySelectorAll('span[steps]');
+  for (var i = 0, line; line = lines[i]; i++) {
+    var steps = line.getAttribute('steps').split(' ');
+    if (steps.indexOf(String(step)) >= 0) {
+      line.classList.add('hl');
+    } else {
+      line.classList.remove('hl');
+    }
+  }
+};
+
+function getCodeStep(pre) {
+  return parseInt(pre.getAttribute('step') || '0', 10);
+};
+
+function stepNextCode() {
+  var pres = getSteppedCode(curSlide);
+  for (var i = 0, pre; pre = pres[i]; i++) {
+    var step = getCodeStep(pre);
+    if (step < parseInt(pre.getAttribute('steps'), 10) - 1) {
+      showCodeStep(pre, step + 1);
+      return true;
+    }
+  }
+  return false;
+};
+
+function stepPrevCode() {
+  var pres = getSteppedCode(curSlide);
+  for (var i = pres.length - 1; i >= 0; i--) {
+    var step = getCodeStep(pres[i]);
+    if (step > 0) {
+      showCodeStep(pres[i], step - 1);
+      return true;
+    }
+  }
+  return false;
+};
+
+/* Code transitions: code that continues the code of the previous slide
+   moves its kept lines into place, fades in its added lines and fades out
+   the removed ones. */
+
+var CODE_TRANSITION = 'transform 0.6s ease-in-out, opacity 0.6s ease-in-out';
+
+function offsetTop(el) {
+  var top = 0;
+  for (; el; el = el.offsetParent) {
+    top += el.offsetTop;
+  }
+  return top;
+};
+
+function offsetLeft(el) {
+  var left = 0;
+  for (; el; el = el.offsetParent) {
+    left += el.offsetLeft;
+  }
+  return left;
+};
+
+function animateCodeTransition(from, to) {
+  var fromEl = getSlideEl(from);
+  var toEl = getSlideEl(to);
+  if (!fromEl || !toEl) {
+    return;
+  }
+  var oldPre = fromEl.querySelector('pre[transition-out]');
+  var newPre = toEl.querySelector('pre[transition-in]');
+  if (!oldPre || !newPre) {
+    return;
+  }
+
+  var oldLines = oldPre.querySelectorAll('span[num]');
+  var newLines = newPre.querySelectorAll('span[num]');
+  var kept = [];
+  var animated = [];
+
+  // Start each line of the new code where it was in the old code.
+  for (var i = 0, line; line = newLines[i]; i++) {
+    var j = parseInt(line.getAttribute('from') || '0', 10) - 1;
+    line.style.transition = 'none';
+    if (j >= 0 && oldLines[j]) {
+      kept[j] = true;
+      var dy = (offsetTop(oldLines[j]) - offsetTop(oldPre)) -
+               (offsetTop(line) - offsetTop(newPre));
+      line.style.transform = 'translateY(' + dy + 'px)';
+    } else {
+      line.style.opacity = '0';
+    }
+    animated.push(line);
+  }
+
+  // Overlay the removed lines, to be faded out.
+  var ghosts = [];
+  for (var j = 0, old; old = oldLines[j]; j++) {
+    if (kept[j]) {
+      continue;
+    }
+    var ghost = old.cloneNode(true);
+    ghost.removeAttribute('num');
+    ghost.classList.add('removed');
+    ghost.style.top = (offsetTop(old) - offsetTop(oldPre)) + 'px';
+    ghost.style.left = (offsetLeft(old) - offsetLeft(oldPre)) + 'px';
+    ghost.style.transition = 'none';
+    newPre.appendChild(ghost);
+    ghosts.push(ghost);
+  }
+
+  newPre.offsetHeight; // Apply the starting positions.
+
+  for (var i = 0, line; line = animated[i]; i++) {
+    line.style.transition = CODE_TRANSITION;
+    line.style.transform = '';
+    line.style.opacity = '';
+  }
+  for (var i = 0, ghost; ghost = ghosts[i]; i++) {
+    ghost.style.transition = CODE_TRANSITION;
+    ghost.style.opacity = '0';
+  }
+  window.setTimeout(function() {
+    for (var i = 0, ghost; ghost = ghosts[i]; i++) {
+      if (ghost.parentNode) {
+        ghost.parentNode.removeChild(ghost);
+      }
+    }
+  }, 600);
+};
+
+function makeBuildLists() {
+  for (var i = curSlide; i < slideEls.length; i++) {
+    var items = getBuildItems(i);
+    for (var j = 0, item; item = items[j]; j++) {
+      item.classList.add('to-build');
+    }
+  }
+};
+
 function prevSlide() {
   hideHelpText();
+  if (buildPrevItem() || stepPrevCode()) {
+    return;
+  }
   if (curSlide > 0) {
     curSlide--;
 
@@ -215,10 +402,14 @@ function prevSlide() {
 
 function nextSlide() {
   hideHelpText();
+  if (stepNextCode() || buildNextItem()) {
+    return;
+  }
   if (curSlide < slideEls.length - 1) {
     curSlide++;
 
     updateSlides();
+    animateCodeTransition(curSlide - 1, curSlide);
   }
 };
 
@@ -355,6 +546,30 @@ function setupFrames() {
   enableSlideFrames(curSlide + 2);
 };
 
+function showArchiveFile(tab) {
+  var code = tab.parentNode.parentNode;
+  var n = tab.getAttribute('file');
+  var tabs = code.querySelectorAll('.txtar-tabs > button');
+  for (var i = 0, t; t = tabs[i]; i++) {
+    t.classList.toggle('active', t == tab);
+  }
+  var files = code.querySelectorAll('.txtar-file');
+  for (var i = 0, f; f = files[i]; i++) {
+    f.classList.toggle('hidden', f.getAttribute('file') != n);
+  }
+};
+
+function setupArchiveTabs() {
+  var tabs = document.querySelectorAll('.txtar-tabs > button');
+  for (var i = 0, tab; tab = tabs[i]; i++) {
+    tab.addEventListener('click', function(e) {
+      e.preventDefault();
+      e.stopPropagation();
+      showArchiveFile(this);
+    }, false);
+  }
+};
+
 function setupInteraction() {
   /* Clicking and tapping */
 
@@ -475,6 +690,8 @@ function handleDomLoaded() {
   slideEls = document.querySelectorAll('section.slides > article');
 
   setupFrames();
+  setupArchiveTabs();
+  makeBuildLists();
 
   addFontStyle();
   addGeneralStyle();